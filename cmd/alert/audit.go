@@ -0,0 +1,158 @@
+package alert
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuditEntry is one line of the audit log: an external command god ran, or
+// an HTTP request it made to an endpoint reached by URL
+type AuditEntry struct {
+	Time   time.Time `json:"time"`
+	User   string    `json:"user"`
+	Target string    `json:"target,omitempty"`
+	Kind   string    `json:"kind"`
+	// Command is the full argv that was executed; Shell is the shell command
+	// god ran through it, if any (e.g. the remote command of an ssh call)
+	Command  string `json:"command"`
+	Shell    string `json:"shell,omitempty"`
+	ExitCode int    `json:"exit_code"`
+	// HTTPStatus is set for direct requests instead of a process exit code
+	HTTPStatus int    `json:"http_status,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+	OutputHash string `json:"output_sha256,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// AuditPath returns the audit log location, $GOD_AUDIT_LOG or
+// $XDG_STATE_HOME/god/audit.jsonl
+func AuditPath() string {
+	if p := os.Getenv("GOD_AUDIT_LOG"); p != "" {
+		return p
+	}
+	dir := stateDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "audit.jsonl")
+}
+
+// auditLog appends entries to the audit log. It is shared by every goroutine
+// of a run; failing to write warns once but never stops the command.
+var auditLog struct {
+	mu   sync.Mutex
+	user string
+	warn sync.Once
+}
+
+func auditUser() string {
+	if auditLog.user == "" {
+		if u, err := user.Current(); err == nil {
+			auditLog.user = u.Username
+		} else {
+			auditLog.user = os.Getenv("USER")
+		}
+	}
+	return auditLog.user
+}
+
+// writeAudit appends one entry. The file is opened O_APPEND for each entry
+// so concurrent god processes never overwrite each other's lines.
+func writeAudit(e AuditEntry) {
+	auditLog.mu.Lock()
+	defer auditLog.mu.Unlock()
+
+	e.User = auditUser()
+	err := func() error {
+		p := AuditPath()
+		if p == "" {
+			return fmt.Errorf("no home directory")
+		}
+		if err := os.MkdirAll(filepath.Dir(p), 0o700); err != nil {
+			return err
+		}
+		// Keep URLs and shell operators readable (no \u0026 escapes)
+		var line bytes.Buffer
+		enc := json.NewEncoder(&line)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(e); err != nil {
+			return err
+		}
+		f, err := os.OpenFile(p, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(line.Bytes()); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}()
+	if err != nil {
+		auditLog.warn.Do(func() {
+			fmt.Fprintf(os.Stderr, "⚠️  Could not write audit log: %v\n", err)
+		})
+	}
+}
+
+// auditEntry fills the target, timing and result fields of an entry
+func auditEntry(t Target, command string, start time.Time, output []byte, err error) AuditEntry {
+	rec := newCommandRecord(command, start, err)
+	e := AuditEntry{
+		Time:       start.UTC(),
+		Target:     t.Name,
+		Kind:       t.Kind(),
+		Command:    command,
+		ExitCode:   rec.ExitCode,
+		DurationMs: rec.Duration.Milliseconds(),
+		Error:      rec.Error,
+	}
+	if t.Name == "" {
+		e.Kind = "local"
+	}
+	if output != nil {
+		sum := sha256.Sum256(output)
+		e.OutputHash = hex.EncodeToString(sum[:])
+	}
+	return e
+}
+
+// audited runs cmd with run, e.g. (*exec.Cmd).CombinedOutput, and records it
+// together with the shell command it carries (empty for plain argv). Use the
+// zero Target for local commands that touch no cluster or server.
+func audited(t Target, cmd *exec.Cmd, shell string, run func(*exec.Cmd) ([]byte, error)) ([]byte, error) {
+	start := time.Now()
+	output, err := run(cmd)
+	e := auditEntry(t, shellJoin(cmd.Args), start, output, err)
+	e.Shell = shell
+	writeAudit(e)
+	return output, err
+}
+
+// runOnly adapts (*exec.Cmd).Run for audited when output is not captured
+func runOnly(cmd *exec.Cmd) ([]byte, error) {
+	return nil, cmd.Run()
+}
+
+// shellJoin renders argv as a command line, quoting only where needed
+func shellJoin(args []string) string {
+	parts := make([]string, len(args))
+	for i, a := range args {
+		if a != "" && strings.Trim(a, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./=:,@%+") == "" {
+			parts[i] = a
+		} else {
+			parts[i] = shellQuote(a)
+		}
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,96 @@
+package alert
+
+import (
+	"bytes"
+	"fmt"
+	"god/cmd/config"
+	"os"
+	"path"
+	"regexp"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fileConfig is the part of the config file read by the alert module
+type fileConfig struct {
+	Alert AlertConfig `yaml:"alert"`
+}
+
+// AlertConfig holds the alert module settings that don't fit on a flag
+type AlertConfig struct {
+	Notify      []NotifyConfig    `yaml:"notify"`
+	Endpoints   []EndpointRule    `yaml:"endpoints"`
+	Remediation RemediationConfig `yaml:"remediation"`
+	Severity    SeverityConfig    `yaml:"severity"`
+	Teleport    TeleportConfig    `yaml:"teleport"`
+}
+
+// configPath returns the location of the config file (--config, GOD_CONFIG
+// or $XDG_CONFIG_HOME/god/config.yaml)
+func configPath() string {
+	return config.Path()
+}
+
+// loadAlertConfig reads the alert section of the config file. A missing file
+// yields an empty config.
+func loadAlertConfig() (*AlertConfig, error) {
+	path := configPath()
+	if path == "" {
+		return &AlertConfig{}, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &AlertConfig{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg fileConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return &cfg.Alert, nil
+}
+
+var lineNumber = regexp.MustCompile(`^line \d+: `)
+
+// ValidateConfig checks the alert section of the config file for unknown
+// keys and values the commands would reject, for god config validate
+func ValidateConfig(section *yaml.Node) []string {
+	data, err := yaml.Marshal(section)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	var cfg AlertConfig
+	var problems []string
+	if err := dec.Decode(&cfg); err != nil {
+		// Line numbers would point into the re-encoded section, not the file
+		typeErr, ok := err.(*yaml.TypeError)
+		if !ok {
+			return []string{err.Error()}
+		}
+		for _, e := range typeErr.Errors {
+			problems = append(problems, lineNumber.ReplaceAllString(e, ""))
+		}
+		return problems
+	}
+
+	if len(cfg.Severity.Order) > 0 {
+		if _, err := parseSeverityOrder(cfg.Severity.Order); err != nil {
+			problems = append(problems, fmt.Sprintf("severity.order: %v", err))
+		}
+	}
+	for _, r := range cfg.Endpoints {
+		if _, err := path.Match(r.Match, ""); err != nil || r.Match == "" {
+			problems = append(problems, fmt.Sprintf("endpoints: invalid match '%s'", r.Match))
+		}
+	}
+	if _, err := cfg.Teleport.cacheTTL(); err != nil {
+		problems = append(problems, fmt.Sprintf("teleport.cache_ttl: invalid duration '%s'", cfg.Teleport.CacheTTL))
+	}
+	return problems
+}
@@ -1,132 +1,149 @@
 package alert
 
 import (
-	"encoding/json"
 	"flag"
 	"fmt"
+	"god/cmd/config"
 	"os"
-	"os/exec"
-	"strings"
+	"time"
 )
 
+// detailsFlags holds the flags of god alert details
+type detailsFlags struct {
+	*flag.FlagSet
+	targets    *targetFlags
+	endpoints  *endpointFlags
+	rulesPath  *string
+	parallel   *int
+	reportPath *string
+	remediate  *bool
+	dryRun     *bool
+}
+
+func newDetailsFlags() *detailsFlags {
+	f := &detailsFlags{FlagSet: flag.NewFlagSet("details", flag.ExitOnError)}
+	f.targets = addTargetFlags(f.FlagSet)
+	f.endpoints = addEndpointFlags(f.FlagSet, true)
+	f.rulesPath = f.String("rules-dir", rulesDir(), "Directory of YAML diagnostic rule files")
+	f.parallel = f.Int("parallel", defaultDiagParallelism, "Maximum diagnoses running at once per cluster")
+	f.reportPath = f.String("report", "", "Also write an incident report to this .md or .html file")
+	f.remediate = f.Bool("remediate", false, "Offer the remediation actions of diagnosed alerts, one confirmation each")
+	f.dryRun = f.Bool("dry-run", false, "With --remediate, only show the actions and commands")
+	return f
+}
+
 func runDetails(args []string) {
-	detailsCmd := flag.NewFlagSet("details", flag.ExitOnError)
-	filter := detailsCmd.String("filter", "", "Filter clusters by name (Teleport)")
-	server := detailsCmd.String("server", "", "Direct SSH connection string (e.g., ubuntu@192.12.3.1)")
-	namespace := detailsCmd.String("n", "monitoring", "Namespace of the Alertmanager service")
-	service := detailsCmd.String("svc", "svc/alertmanager-operated", "Service name to port-forward")
-	port := detailsCmd.String("port", "9093", "Local port to use")
-	detailsCmd.Parse(args)
-
-	if *filter == "" && *server == "" {
-		fmt.Println("❌ Error: Must provide either --filter (for Teleport) or --server (for SSH)")
+	f := newDetailsFlags()
+	tf, ef, rulesPath, parallel, reportPath, remediate, dryRun := f.targets, f.endpoints, f.rulesPath, f.parallel, f.reportPath, f.remediate, f.dryRun
+	config.Apply(f.FlagSet, "alert")
+	f.Parse(args)
+	configureEndpoints(ef)
+
+	if tf.empty() {
+		fmt.Println("❌ Error: Must provide --filter (Teleport), --contexts (kubeconfig), --server or --group (SSH)")
 		os.Exit(1)
 	}
 
-	// --- Smart Namespace Override ---
-	actualNamespace := *namespace
-	if *server != "" && actualNamespace == "monitoring" {
-		actualNamespace = "monitoring-linuxaid"
+	if *reportPath != "" {
+		if _, err := reportFormat(*reportPath); err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+			os.Exit(1)
+		}
 	}
 
-	// --- BRANCH 1: Direct SSH Server ---
-	if *server != "" {
-		fmt.Printf("\n--------------------------------------------------\n")
-		fmt.Printf("🌐 Connecting via SSH to: %s\n", *server)
-
-		alerts, err := FetchAlerts(*server, actualNamespace, *service, *port)
+	var fixer *remediator
+	if *remediate || *dryRun {
+		if !*dryRun && !isTerminal(os.Stdin) {
+			fmt.Println("❌ Error: --remediate needs an interactive terminal (use --dry-run to preview)")
+			os.Exit(1)
+		}
+		cfg, err := loadAlertConfig()
 		if err != nil {
-			fmt.Printf("⚠️  Could not fetch alerts: %v\n", err)
-			return
+			fmt.Printf("❌ Error loading config: %v\n", err)
+			os.Exit(1)
 		}
-
-		processAlerts(alerts, *server)
-		return
-	}
-
-	// --- BRANCH 2: Teleport Discovery ---
-	if _, err := exec.LookPath("tsh"); err != nil {
-		fmt.Println("❌ Error: 'tsh' is not installed.")
-		os.Exit(1)
-	}
-
-	fmt.Println("🔍 Fetching clusters from Teleport...")
-	cmd := exec.Command("tsh", "kube", "ls", "--format=json")
-	output, err := cmd.Output()
-	if err != nil {
-		fmt.Printf("❌ Failed to run 'tsh kube ls': %v\n", err)
-		os.Exit(1)
-	}
-
-	var allClusters []TSHCluster
-	if err := json.Unmarshal(output, &allClusters); err != nil {
-		fmt.Printf("❌ Failed to parse tsh output: %v\n", err)
-		os.Exit(1)
+		fixer = newRemediator(cfg.Remediation, *dryRun)
 	}
 
-	var targetClusters []string
-	for _, c := range allClusters {
-		if strings.Contains(c.Name, *filter) {
-			targetClusters = append(targetClusters, c.Name)
-		}
-	}
+	loadRuleFiles(*rulesPath)
 
-	if len(targetClusters) == 0 {
-		fmt.Printf("⚠️  No clusters found matching '%s'\n", *filter)
+	targets := tf.targets()
+	if len(targets) == 0 {
 		return
 	}
+	defer releaseTargets(targets)
+	defer releaseOnSignal(targets)()
 
-	fmt.Printf("🚀 Found %d clusters matching '%s'. Starting diagnosis...\n", len(targetClusters), *filter)
+	fmt.Printf("🚀 Diagnosing %d targets...\n", len(targets))
+	report := &Report{Started: time.Now(), Targets: tf.String()}
 
-	for _, cluster := range targetClusters {
+	for _, t := range targets {
 		fmt.Printf("\n--------------------------------------------------\n")
-		fmt.Printf("🌐 Connecting to: %s\n", cluster)
+		if t.IsSSH() && t.Name != t.Server {
+			fmt.Printf("🌐 Connecting via SSH to: %s (%s)\n", t.Name, t.Server)
+		} else if t.IsSSH() {
+			fmt.Printf("🌐 Connecting via SSH to: %s\n", t.Server)
+		} else {
+			fmt.Printf("🌐 Connecting to: %s (%s)\n", t.Name, t.Kind())
+		}
+
+		rc := &ReportCluster{Name: t.Name, Kind: t.Kind(), Time: time.Now()}
+		report.Clusters = append(report.Clusters, rc)
 
-		loginCmd := exec.Command("tsh", "kube", "login", cluster)
-		if out, err := loginCmd.CombinedOutput(); err != nil {
-			fmt.Printf("❌ Login failed: %v\n%s\n", err, string(out))
+		if err := t.Connect(); err != nil {
+			fmt.Printf("❌ Login failed: %v\n", err)
+			rc.Error = "login failed: " + err.Error()
 			continue
 		}
 
 		fmt.Printf("🔌 Checking alerts...\n")
-		alerts, err := FetchAlerts("", actualNamespace, *service, *port)
+		alerts, fetch, err := fetchAlerts(t)
+		if fetch.Command != "" {
+			rc.Commands = append(rc.Commands, fetch)
+		}
 		if err != nil {
 			fmt.Printf("⚠️  Could not fetch alerts: %v\n", err)
+			rc.Error = err.Error()
 			continue
 		}
+		rc.Alerts = alerts
 
-		processAlerts(alerts, "")
+		// One at a time over SSH: each command may prompt for a YubiKey touch
+		workers := *parallel
+		if t.IsSSH() {
+			workers = 1
+		}
+		jobs := processAlerts(alerts, t, workers)
+		rc.addDiagnoses(jobs)
+		if fixer != nil {
+			rc.Remediations = fixer.offer(t, jobs)
+		}
+	}
+
+	if *reportPath != "" {
+		report.Finished = time.Now()
+		if err := writeReport(*reportPath, report); err != nil {
+			fmt.Printf("❌ Could not write report: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("📝 Report written to %s\n", *reportPath)
 	}
 }
 
-// Helper to keep logic DRY
-func processAlerts(alerts []Alert, server string) {
+// Helper to keep logic DRY; returns the finished diagnoses
+func processAlerts(alerts []Alert, t Target, parallel int) []*diagJob {
 	if len(alerts) == 0 {
 		fmt.Println("✅ No active alerts.")
-		return
+		return nil
 	}
 
 	fmt.Printf("🔥 Found %d active alerts:\n", len(alerts))
-	processedRules := make(map[string]bool)
-
 	for _, alert := range alerts {
-		name := alert.Labels["alertname"]
-		ns := alert.Labels["namespace"]
-		target := "cluster-wide"
-		if pod, ok := alert.Labels["pod"]; ok {
-			target = pod
-		}
-		if ns == "" {
-			ns = "global"
-		}
-
-		fmt.Printf("   🔴 %-35s -> %s/%s\n", name, ns, target)
-
-		if ruleFunc, exists := DiagnosticRules[name]; exists {
-			if !processedRules[name] {
-				ruleFunc(alert, server)
-				processedRules[name] = true
-			}
-		}
+		fmt.Printf("   🔴 %-35s -> %s\n", alert.Labels["alertname"], alertTarget(alert))
 	}
+
+	jobs := planDiagnoses(alerts, t)
+	runDiagnoses(jobs, parallel)
+	fmt.Println("")
+	return jobs
 }
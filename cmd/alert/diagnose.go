@@ -0,0 +1,272 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// defaultDiagParallelism limits how many diagnoses run at once per cluster
+const defaultDiagParallelism = 4
+
+// RuleScope controls how often a rule runs when its alert fires many times
+type RuleScope int
+
+const (
+	// PerInstance runs the rule once per distinct dedup key (by default the
+	// alert fingerprint), e.g. once for every failing Velero schedule
+	PerInstance RuleScope = iota
+	// PerCluster runs the rule once per cluster for the first matching alert,
+	// for checks whose output doesn't depend on the alert's labels
+	PerCluster
+)
+
+// RuleFunc defines the function signature for a diagnostic check
+type RuleFunc func(d *Diagnosis, alert Alert)
+
+// Rule is a diagnostic check together with how it is deduplicated
+type Rule struct {
+	Name  string
+	Scope RuleScope
+	// DedupKey groups PerInstance alerts that share one diagnosis;
+	// nil means the alert fingerprint
+	DedupKey func(alert Alert) string
+	Run      RuleFunc
+	// Actions are the remediations offered by `details --remediate`
+	Actions []Action
+}
+
+// Diagnosis collects the output of a single rule run so that rules can run
+// concurrently and still be printed in a stable order
+type Diagnosis struct {
+	Target   Target
+	Started  time.Time
+	Finished time.Time
+	// Commands lists everything the rule executed, for --report
+	Commands []CommandRecord
+	out      bytes.Buffer
+}
+
+// CommandRecord is one command or query a diagnosis executed
+type CommandRecord struct {
+	Command  string
+	Started  time.Time
+	Duration time.Duration
+	// ExitCode is -1 when the command could not run or was killed
+	ExitCode int
+	Error    string
+}
+
+// Printf appends formatted output to the diagnosis
+func (d *Diagnosis) Printf(format string, a ...interface{}) {
+	fmt.Fprintf(&d.out, format, a...)
+}
+
+// Println appends a line to the diagnosis
+func (d *Diagnosis) Println(a ...interface{}) {
+	fmt.Fprintln(&d.out, a...)
+}
+
+// Writer exposes the buffer for helpers such as tabwriter
+func (d *Diagnosis) Writer() io.Writer {
+	return &d.out
+}
+
+// Output returns everything the diagnosis printed so far
+func (d *Diagnosis) Output() string {
+	return d.out.String()
+}
+
+// Run executes a command on the diagnosis target (locally or over SSH)
+func (d *Diagnosis) Run(cmdStr string) ([]byte, error) {
+	return d.RunContext(context.Background(), cmdStr)
+}
+
+// RunContext is Run with a deadline or cancellation
+func (d *Diagnosis) RunContext(ctx context.Context, cmdStr string) ([]byte, error) {
+	start := time.Now()
+	output, err := runCommandContext(ctx, d.Target, cmdStr)
+	d.record(cmdStr, start, err)
+	return output, err
+}
+
+// Query runs an instant PromQL query against the target's Prometheus
+func (d *Diagnosis) Query(ctx context.Context, promQuery string) (*PromResponse, error) {
+	start := time.Now()
+	resp, err := queryPrometheus(ctx, d.Target, promQuery)
+	d.record("promql: "+promQuery, start, err)
+	return resp, err
+}
+
+// PrometheusGet fetches a Prometheus HTTP API path from the target
+func (d *Diagnosis) PrometheusGet(ctx context.Context, path string) ([]byte, error) {
+	start := time.Now()
+	body, err := prometheusGet(ctx, d.Target, path)
+	d.record("prometheus: GET "+path, start, err)
+	return body, err
+}
+
+func (d *Diagnosis) record(cmdStr string, start time.Time, err error) {
+	d.Commands = append(d.Commands, newCommandRecord(cmdStr, start, err))
+}
+
+// newCommandRecord describes a finished command
+func newCommandRecord(cmdStr string, start time.Time, err error) CommandRecord {
+	rec := CommandRecord{Command: cmdStr, Started: start, Duration: time.Since(start)}
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+	case errors.As(err, &exitErr):
+		rec.ExitCode = exitErr.ExitCode()
+		rec.Error = err.Error()
+	default:
+		rec.ExitCode = -1
+		rec.Error = err.Error()
+	}
+	return rec
+}
+
+// diagJob is one scheduled rule run for one alert
+type diagJob struct {
+	rule  Rule
+	alert Alert
+	diag  *Diagnosis
+	done  chan struct{}
+}
+
+// rulesFor returns every built-in and file rule that applies to the alert
+func rulesFor(alert Alert) []Rule {
+	var rules []Rule
+	if rule, exists := DiagnosticRules[alert.Labels["alertname"]]; exists {
+		rules = append(rules, rule)
+	}
+	for _, fr := range fileRules {
+		if fr.Matches(alert) {
+			rules = append(rules, fr.Rule())
+		}
+	}
+	return rules
+}
+
+// planDiagnoses turns a list of alerts into the deduplicated rule runs,
+// in the order the alerts were listed
+func planDiagnoses(alerts []Alert, t Target) []*diagJob {
+	var jobs []*diagJob
+	seen := make(map[string]bool)
+
+	for _, alert := range alerts {
+		for _, rule := range rulesFor(alert) {
+			key := rule.Name
+			if rule.Scope == PerInstance {
+				if rule.DedupKey != nil {
+					key += "|" + rule.DedupKey(alert)
+				} else {
+					key += "|" + alert.Key()
+				}
+			}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			jobs = append(jobs, &diagJob{
+				rule:  rule,
+				alert: alert,
+				diag:  &Diagnosis{Target: t},
+				done:  make(chan struct{}),
+			})
+		}
+	}
+	return jobs
+}
+
+// runDiagnoses runs the jobs with bounded parallelism and prints each one's
+// output as soon as it and all jobs before it have finished
+func runDiagnoses(jobs []*diagJob, parallel int) {
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	// Buffered channel acting as a semaphore, as in `god git pull`
+	sem := make(chan struct{}, parallel)
+
+	go func() {
+		for _, job := range jobs {
+			sem <- struct{}{}
+			go func(j *diagJob) {
+				defer func() { <-sem }()
+				defer close(j.done)
+				j.diag.Started = time.Now()
+				j.rule.Run(j.diag, j.alert)
+				j.diag.Finished = time.Now()
+			}(job)
+		}
+	}()
+
+	for _, job := range jobs {
+		<-job.done
+		fmt.Printf("\n🩺 %s → %s\n", job.rule.Name, alertTarget(job.alert))
+		os.Stdout.Write(job.diag.out.Bytes())
+	}
+}
+
+// --- Output Helpers ---
+
+// diagnose runs one command and prints its output in the diagnosis style
+func (d *Diagnosis) diagnose(title, cmdStr string, maxLines int) {
+	d.Printf("\n   🔍 [Diagnosis] %s\n", title)
+	output, err := d.Run(cmdStr)
+	if err != nil {
+		d.Printf("      ❌ Command failed: %v\n", err)
+	}
+	d.printTruncated(string(output), maxLines)
+}
+
+// printTruncated prints command output in the indented diagnosis style,
+// keeping at most maxLines lines
+func (d *Diagnosis) printTruncated(output string, maxLines int) {
+	cleanOutput := strings.ReplaceAll(output, "\r", "")
+	cleanOutput = strings.TrimRight(cleanOutput, "\n")
+	if cleanOutput == "" {
+		return
+	}
+
+	lines := strings.Split(cleanOutput, "\n")
+	for i, line := range lines {
+		if maxLines > 0 && i >= maxLines {
+			d.Printf("      … (%d more lines)\n", len(lines)-maxLines)
+			break
+		}
+		d.Printf("      %s\n", line)
+	}
+}
+
+// requireLabels returns the values of the labels a rule needs, printing a
+// note and returning false when the alert doesn't carry all of them
+func (d *Diagnosis) requireLabels(alert Alert, names ...string) ([]string, bool) {
+	values := make([]string, len(names))
+	for i, name := range names {
+		values[i] = alert.Labels[name]
+		if values[i] == "" {
+			d.Printf("\n   🔍 [Diagnosis] Skipped: alert has no '%s' label\n", name)
+			return nil, false
+		}
+	}
+	return values, true
+}
+
+// labelKey builds a DedupKey from the given labels
+func labelKey(names ...string) func(Alert) string {
+	return func(alert Alert) string {
+		values := make([]string, len(names))
+		for i, name := range names {
+			values[i] = alert.Labels[name]
+		}
+		return strings.Join(values, "/")
+	}
+}
@@ -0,0 +1,245 @@
+package alert
+
+import (
+	"flag"
+	"fmt"
+	"god/cmd/config"
+	"os"
+	"sort"
+	"time"
+)
+
+// diffFlags holds the flags of god alert diff
+type diffFlags struct {
+	*flag.FlagSet
+	since   *string
+	list    *bool
+	targets *targetFlags
+}
+
+func newDiffFlags() *diffFlags {
+	f := &diffFlags{FlagSet: flag.NewFlagSet("diff", flag.ExitOnError)}
+	f.since = f.String("since", "", "Baseline: a run ID or a duration like 8h (default: the previous run)")
+	f.list = f.Bool("list", false, "List saved scan runs and exit")
+	f.targets = addTargetFlags(f.FlagSet)
+	return f
+}
+
+func runDiff(args []string) {
+	f := newDiffFlags()
+	since, list, tf := f.since, f.list, f.targets
+	config.Apply(f.FlagSet, "alert")
+	f.Parse(args)
+
+	dir := scansDir()
+	ids, err := listScanRunIDs(dir)
+	if err != nil {
+		fmt.Printf("❌ Error reading %s: %v\n", dir, err)
+		os.Exit(1)
+	}
+	// Target flags select the runs saved by a scan with the same flags;
+	// without them the latest run decides
+	selected := !tf.empty()
+	if selected {
+		ids = scanRunsWithFilter(dir, ids, tf.String())
+	}
+
+	if *list {
+		printScanRuns(dir, ids)
+		return
+	}
+
+	if len(ids) == 0 && selected {
+		fmt.Printf("⚠️  No saved scan with filter %q in %s (run `god alert scan --save` with the same flags)\n", tf.String(), dir)
+		return
+	}
+	if len(ids) < 2 && !selected {
+		fmt.Printf("⚠️  Need at least two saved scans in %s (run `god alert scan --save`)\n", dir)
+		return
+	}
+
+	latestID := ids[len(ids)-1]
+	latest, err := loadScanRun(dir, latestID)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Runs of another filter scanned other clusters, so they'd show their
+	// alerts as resolved
+	older := scanRunsWithFilter(dir, ids[:len(ids)-1], latest.Filter)
+	if len(older) == 0 {
+		fmt.Printf("⚠️  No earlier saved scan with filter %q in %s (run `god alert scan --save` with it)\n", latest.Filter, dir)
+		return
+	}
+	baseID, err := pickBaseline(dir, older, *since)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	base, err := loadScanRun(dir, baseID)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("🔀 Comparing %s (%s) against %s (%s)\n",
+		latest.ID, formatDuration(time.Since(latest.Time))+" ago",
+		base.ID, formatDuration(time.Since(base.Time))+" ago")
+	printRunDiff(base, latest)
+}
+
+// pickBaseline resolves --since against the runs older than the latest one
+// that share its filter
+func pickBaseline(dir string, older []string, since string) (string, error) {
+	if since == "" {
+		return older[len(older)-1], nil
+	}
+
+	for _, id := range older {
+		if id == since {
+			return id, nil
+		}
+	}
+	if run, err := loadScanRun(dir, since); err == nil {
+		return "", fmt.Errorf("run %s was saved with filter %q, not the compared run's; compare runs of the same filter", since, run.Filter)
+	}
+
+	d, err := ParseLongDuration(since)
+	if err != nil {
+		return "", fmt.Errorf("--since is neither a saved run ID nor a duration: %v", err)
+	}
+
+	// The newest run taken at or before the cutoff; the oldest run otherwise
+	cutoff := time.Now().Add(-d)
+	baseline := older[0]
+	for _, id := range older {
+		run, err := loadScanRun(dir, id)
+		if err != nil {
+			continue
+		}
+		if run.Time.After(cutoff) {
+			break
+		}
+		baseline = id
+	}
+	return baseline, nil
+}
+
+func printScanRuns(dir string, ids []string) {
+	if len(ids) == 0 {
+		fmt.Printf("No saved scans in %s\n", dir)
+		return
+	}
+	for _, id := range ids {
+		run, err := loadScanRun(dir, id)
+		if err != nil {
+			fmt.Printf("   %s  ⚠️  %v\n", id, err)
+			continue
+		}
+		total := 0
+		for _, c := range run.Clusters {
+			total += len(c.Alerts)
+		}
+		fmt.Printf("   %s  %3d clusters  %4d alerts  filter=%q\n", run.ID, len(run.Clusters), total, run.Filter)
+	}
+}
+
+// printRunDiff shows new, resolved and persisting alerts per cluster
+func printRunDiff(base, latest *ScanRun) {
+	baseClusters := make(map[string]ScanCluster)
+	for _, c := range base.Clusters {
+		baseClusters[c.Name] = c
+	}
+	latestClusters := make(map[string]ScanCluster)
+	for _, c := range latest.Clusters {
+		latestClusters[c.Name] = c
+	}
+
+	names := make([]string, 0, len(baseClusters)+len(latestClusters))
+	for name := range latestClusters {
+		names = append(names, name)
+	}
+	for name := range baseClusters {
+		if _, ok := latestClusters[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var totalNew, totalResolved, totalPersisting int
+	for _, name := range names {
+		fmt.Printf("\n🌐 %s\n", name)
+
+		b, inBase := baseClusters[name]
+		l, inLatest := latestClusters[name]
+		switch {
+		case !inLatest:
+			fmt.Println("   ⚠️  Not scanned in the latest run")
+			continue
+		case l.Error != "":
+			fmt.Printf("   ⚠️  Latest scan failed: %s\n", l.Error)
+			continue
+		case !inBase:
+			fmt.Println("   ℹ️  Not in the baseline run; all alerts are shown as new")
+		case b.Error != "":
+			fmt.Println("   ℹ️  Baseline scan failed; all alerts are shown as new")
+		}
+
+		prev := make(map[string]Alert)
+		for _, a := range b.Alerts {
+			prev[a.Key()] = a
+		}
+		curr := make(map[string]Alert)
+		for _, a := range l.Alerts {
+			curr[a.Key()] = a
+		}
+
+		var added, resolved, persisting []Alert
+		for key, a := range curr {
+			if _, ok := prev[key]; ok {
+				persisting = append(persisting, a)
+			} else {
+				added = append(added, a)
+			}
+		}
+		for key, a := range prev {
+			if _, ok := curr[key]; !ok && b.Error == "" {
+				resolved = append(resolved, a)
+			}
+		}
+
+		if len(added)+len(resolved)+len(persisting) == 0 {
+			fmt.Println("   ✅ No alerts in either run")
+			continue
+		}
+		printDiffSection("🆕 New", added)
+		printDiffSection("✅ Resolved", resolved)
+		printDiffSection("⏳ Persisting", persisting)
+
+		totalNew += len(added)
+		totalResolved += len(resolved)
+		totalPersisting += len(persisting)
+	}
+
+	fmt.Printf("\n--- %d new, %d resolved, %d persisting ---\n", totalNew, totalResolved, totalPersisting)
+}
+
+func printDiffSection(title string, alerts []Alert) {
+	if len(alerts) == 0 {
+		return
+	}
+	fmt.Printf("   %s (%d)\n", title, len(alerts))
+	for _, a := range sortedAlerts(alertMap(alerts)) {
+		fmt.Printf("      %-35s -> %s\n", a.Labels["alertname"], alertTarget(a))
+	}
+}
+
+// alertMap keys alerts by fingerprint
+func alertMap(alerts []Alert) map[string]Alert {
+	m := make(map[string]Alert, len(alerts))
+	for _, a := range alerts {
+		m[a.Key()] = a
+	}
+	return m
+}
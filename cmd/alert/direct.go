@@ -0,0 +1,275 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// directTimeout bounds a request to an endpoint reached by URL
+const directTimeout = 30 * time.Second
+
+// HTTPConfig holds how to reach an endpoint by URL: authentication, TLS
+// and proxy. Basic auth may also be given in the URL (https://user@host);
+// the password then comes from the URL or $GOD_HTTP_PASSWORD.
+type HTTPConfig struct {
+	BasicAuth       *BasicAuth `yaml:"basic_auth"`
+	BearerTokenFile string     `yaml:"bearer_token_file"`
+	BearerTokenEnv  string     `yaml:"bearer_token_env"`
+	TLS             TLSConfig  `yaml:"tls"`
+	ProxyURL        string     `yaml:"proxy_url"`
+}
+
+// BasicAuth credentials; the password is read from the environment or a
+// file so it never has to be written into config.yaml
+type BasicAuth struct {
+	Username     string `yaml:"username"`
+	PasswordEnv  string `yaml:"password_env"`
+	PasswordFile string `yaml:"password_file"`
+}
+
+// TLSConfig configures server verification and client certificates
+type TLSConfig struct {
+	CAFile             string `yaml:"ca_file"`
+	CertFile           string `yaml:"cert_file"`
+	KeyFile            string `yaml:"key_file"`
+	ServerName         string `yaml:"server_name"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+}
+
+// httpClients holds one client per TLS and proxy setting, so certificate
+// files are read once and connections are reused across requests
+var httpClients struct {
+	mu      sync.Mutex
+	clients map[httpClientKey]*http.Client
+}
+
+// httpClientKey is the part of an HTTPConfig a client is built from
+type httpClientKey struct {
+	tls      TLSConfig
+	proxyURL string
+}
+
+// client returns the HTTP client for the TLS and proxy settings, building
+// it on first use
+func (c HTTPConfig) client() (*http.Client, error) {
+	key := httpClientKey{tls: c.TLS, proxyURL: c.ProxyURL}
+	httpClients.mu.Lock()
+	defer httpClients.mu.Unlock()
+	if client, ok := httpClients.clients[key]; ok {
+		return client, nil
+	}
+
+	client, err := c.newClient()
+	if err != nil {
+		return nil, err
+	}
+	if httpClients.clients == nil {
+		httpClients.clients = map[httpClientKey]*http.Client{}
+	}
+	httpClients.clients[key] = client
+	return client, nil
+}
+
+// newClient builds an HTTP client for the TLS and proxy settings
+func (c HTTPConfig) newClient() (*http.Client, error) {
+	tlsConfig := &tls.Config{
+		ServerName:         c.TLS.ServerName,
+		InsecureSkipVerify: c.TLS.InsecureSkipVerify,
+	}
+
+	if c.TLS.CAFile != "" {
+		pem, err := os.ReadFile(expandHome(c.TLS.CAFile))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA file: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", c.TLS.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if c.TLS.CertFile != "" || c.TLS.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(expandHome(c.TLS.CertFile), expandHome(c.TLS.KeyFile))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %v", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	if c.ProxyURL != "" {
+		proxy, err := url.Parse(c.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %v", err)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	return &http.Client{Transport: transport, Timeout: directTimeout}, nil
+}
+
+// authorize adds basic auth or a bearer token to the request
+func (c HTTPConfig) authorize(req *http.Request, u *url.URL) error {
+	if c.BasicAuth != nil {
+		password, err := secret(c.BasicAuth.PasswordEnv, c.BasicAuth.PasswordFile)
+		if err != nil {
+			return err
+		}
+		req.SetBasicAuth(c.BasicAuth.Username, password)
+		return nil
+	}
+
+	if u.User != nil {
+		password, ok := u.User.Password()
+		if !ok {
+			password = os.Getenv("GOD_HTTP_PASSWORD")
+		}
+		req.SetBasicAuth(u.User.Username(), password)
+		return nil
+	}
+
+	token, err := secret(c.BearerTokenEnv, c.BearerTokenFile)
+	if err != nil {
+		return err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return nil
+}
+
+// secret reads a credential from a file, or else from an environment variable
+func secret(env, file string) (string, error) {
+	if file != "" {
+		data, err := os.ReadFile(expandHome(file))
+		if err != nil {
+			return "", fmt.Errorf("failed to read credentials: %v", err)
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+	if env != "" {
+		return os.Getenv(env), nil
+	}
+	return "", nil
+}
+
+// directGet requests path from an endpoint reached by URL and returns the
+// body. The URL may carry a path prefix (e.g. https://host/alertmanager).
+// Every request is recorded in the audit log for the target.
+func directGet(ctx context.Context, t Target, e Endpoint, path string) ([]byte, error) {
+	return directDo(ctx, t, e, http.MethodGet, path, nil)
+}
+
+// directPost sends a JSON body to path, e.g. to create a silence
+func directPost(ctx context.Context, t Target, e Endpoint, path string, body []byte) ([]byte, error) {
+	return directDo(ctx, t, e, http.MethodPost, path, body)
+}
+
+func directDo(ctx context.Context, t Target, e Endpoint, method, path string, reqBody []byte) ([]byte, error) {
+	start := time.Now()
+	status := 0
+	body, err := directRequest(ctx, e, method, path, reqBody, &status)
+
+	entry := auditEntry(t, method+" "+redactedURL(e.URL+path), start, body, err)
+	entry.Kind = "http"
+	entry.HTTPStatus = status
+	if status != 0 {
+		// The status stands in for the exit code; Error is set for non-2xx
+		entry.ExitCode = 0
+	}
+	writeAudit(entry)
+	return body, err
+}
+
+// redactedURL hides the password of a URL for the audit log and reports
+func redactedURL(s string) string {
+	if u, err := url.Parse(s); err == nil {
+		return u.Redacted()
+	}
+	return s
+}
+
+func directRequest(ctx context.Context, e Endpoint, method, path string, reqBody []byte, status *int) ([]byte, error) {
+	u, err := url.Parse(e.URL + path)
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint URL: %v", err)
+	}
+
+	client, err := e.HTTP.client()
+	if err != nil {
+		return nil, err
+	}
+
+	var bodyReader io.Reader
+	if reqBody != nil {
+		bodyReader = bytes.NewReader(reqBody)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bodyReader)
+	if err != nil {
+		return nil, err
+	}
+	if reqBody != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if err := e.HTTP.authorize(req, u); err != nil {
+		return nil, err
+	}
+	// Credentials in the URL travel in the header only
+	req.URL.User = nil
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	*status = resp.StatusCode
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &httpStatusError{
+			status: resp.Status,
+			body:   body,
+			json:   strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json"),
+		}
+	}
+	return body, nil
+}
+
+// httpStatusError is a non-2xx response. It keeps the body because
+// Prometheus explains failed queries in a JSON error body.
+type httpStatusError struct {
+	status string
+	body   []byte
+	json   bool
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("%s: %s", e.status, Truncate(strings.TrimSpace(string(e.body)), 200))
+}
+
+// expandHome expands a leading ~/ in paths from config and flags
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return home + path[1:]
+}
@@ -0,0 +1,72 @@
+package alert
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var longDurationRe = regexp.MustCompile(`(\d+)([wdhms])`)
+
+// ParseLongDuration parses durations such as "7d", "1w", "12h" or "1d12h".
+// Unlike time.ParseDuration it accepts days (d) and weeks (w), and it
+// rejects durations that are zero or negative.
+func ParseLongDuration(s string) (time.Duration, error) {
+	d, err := parseLongDuration(s)
+	if err == nil && d <= 0 {
+		return 0, fmt.Errorf("duration %q must be positive", s)
+	}
+	return d, err
+}
+
+func parseLongDuration(s string) (time.Duration, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return d, nil
+	}
+
+	matches := longDurationRe.FindAllStringSubmatchIndex(s, -1)
+	if matches == nil {
+		return 0, fmt.Errorf("invalid duration %q (e.g. 30m, 12h, 7d, 2w)", s)
+	}
+
+	units := map[string]time.Duration{
+		"w": 7 * 24 * time.Hour,
+		"d": 24 * time.Hour,
+		"h": time.Hour,
+		"m": time.Minute,
+		"s": time.Second,
+	}
+
+	var total time.Duration
+	pos := 0
+	for _, m := range matches {
+		if m[0] != pos {
+			return 0, fmt.Errorf("invalid duration %q (e.g. 30m, 12h, 7d, 2w)", s)
+		}
+		n, _ := strconv.Atoi(s[m[2]:m[3]])
+		total += time.Duration(n) * units[s[m[4]:m[5]]]
+		pos = m[1]
+	}
+	if pos != len(s) {
+		return 0, fmt.Errorf("invalid duration %q (e.g. 30m, 12h, 7d, 2w)", s)
+	}
+	return total, nil
+}
+
+// formatDuration renders a duration compactly, e.g. "2d3h", "3h12m" or "45s"
+func formatDuration(d time.Duration) string {
+	if d < 0 {
+		d = -d
+	}
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh%dm", int(d.Hours()), int(d.Minutes())%60)
+	default:
+		return fmt.Sprintf("%dd%dh", int(d.Hours())/24, int(d.Hours())%24)
+	}
+}
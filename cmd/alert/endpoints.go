@@ -0,0 +1,543 @@
+package alert
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"god/cmd/config"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Endpoint is a monitoring service, reached through the kube API proxy or
+// directly by URL
+type Endpoint struct {
+	Namespace string
+	Service   string
+	Port      string
+
+	// URL and HTTP are set for endpoints reached directly
+	URL  string
+	HTTP HTTPConfig
+
+	// Source says how the endpoint was chosen: flag, inventory, config,
+	// discovered or default
+	Source string
+}
+
+// ProxyPath returns the kube API proxy path for an HTTP path on the service
+func (e Endpoint) ProxyPath(p string) string {
+	return fmt.Sprintf("/api/v1/namespaces/%s/services/%s:%s/proxy%s", e.Namespace, e.Service, e.Port, p)
+}
+
+// Direct reports whether the endpoint is reached by URL
+func (e Endpoint) Direct() bool {
+	return e.URL != ""
+}
+
+func (e Endpoint) String() string {
+	if e.Direct() {
+		return fmt.Sprintf("%s (%s)", redactedURL(e.URL), e.Source)
+	}
+	return fmt.Sprintf("%s/%s:%s (%s)", e.Namespace, e.Service, e.Port, e.Source)
+}
+
+// EndpointConfig overrides one service in config.yaml: either a service
+// reached through the kube API proxy, where empty fields fall back to the
+// defaults of the component, or a URL reached directly.
+type EndpointConfig struct {
+	Namespace string `yaml:"namespace"`
+	Service   string `yaml:"service"`
+	Port      string `yaml:"port"`
+
+	URL        string `yaml:"url"`
+	HTTPConfig `yaml:",inline"`
+}
+
+// EndpointRule overrides the services of every target whose name matches
+// the glob. The first matching rule wins.
+//
+//	alert:
+//	  endpoints:
+//	    - match: "prod-*"
+//	      alertmanager: {namespace: monitoring, service: alertmanager-main}
+//	      prometheus: {namespace: monitoring, service: prometheus-k8s}
+//	    - match: "edge-eu"
+//	      prometheus:
+//	        url: https://prometheus.edge-eu.example.com
+//	        bearer_token_file: ~/.config/god/edge-eu.token
+type EndpointRule struct {
+	Match        string          `yaml:"match"`
+	Alertmanager *EndpointConfig `yaml:"alertmanager"`
+	Prometheus   *EndpointConfig `yaml:"prometheus"`
+}
+
+// component describes how to find one kind of monitoring service
+type component struct {
+	name           string
+	defaultService string
+	defaultPort    string
+	// selectors identify the service, in order of preference. The
+	// Prometheus Operator labels the governing services of its
+	// Alertmanager and Prometheus resources with operated-*=true.
+	selectors [][2]string
+}
+
+var (
+	alertmanagerComponent = component{
+		name:           "Alertmanager",
+		defaultService: "alertmanager-operated",
+		defaultPort:    "9093",
+		selectors: [][2]string{
+			{"operated-alertmanager", "true"},
+			{"app.kubernetes.io/name", "alertmanager"},
+			{"app", "alertmanager"},
+		},
+	}
+	prometheusComponent = component{
+		name:           "Prometheus",
+		defaultService: "prometheus-operated",
+		defaultPort:    "9090",
+		selectors: [][2]string{
+			{"operated-prometheus", "true"},
+			{"app.kubernetes.io/name", "prometheus"},
+			{"app", "prometheus"},
+		},
+	}
+)
+
+// webPortNames are preferred, in order, when a service exposes several ports
+var webPortNames = []string{"web", "http-web", "http"}
+
+// --- Endpoint Flags ---
+
+// endpointFlags select the monitoring services of a command. When none is
+// given the services are discovered per target.
+type endpointFlags struct {
+	fs *flag.FlagSet
+
+	// Alertmanager service, only for commands that talk to Alertmanager
+	namespace string
+	service   string
+	port      string
+	amURL     string
+
+	promURL string
+	http    HTTPConfig
+}
+
+// addEndpointFlags registers the endpoint flags; alertmanager adds -n,
+// --svc, --port and --am-url for commands that fetch alerts
+func addEndpointFlags(fs *flag.FlagSet, alertmanager bool) *endpointFlags {
+	f := &endpointFlags{fs: fs}
+	if alertmanager {
+		fs.StringVar(&f.namespace, "n", "", "Namespace of the Alertmanager service (default: discovered)")
+		fs.StringVar(&f.service, "svc", "", "Alertmanager service name (default: discovered)")
+		fs.StringVar(&f.port, "port", "", "Alertmanager service port (default: discovered)")
+		fs.StringVar(&f.amURL, "am-url", "", "Reach Alertmanager directly at this URL instead of via the kube API proxy")
+	}
+	fs.StringVar(&f.promURL, "prom-url", "", "Reach Prometheus directly at this URL instead of via the kube API proxy")
+	fs.StringVar(&f.http.BearerTokenFile, "bearer-token-file", "", "Bearer token file for --am-url/--prom-url (default: $GOD_BEARER_TOKEN)")
+	fs.StringVar(&f.http.TLS.CAFile, "ca-file", "", "CA certificate for --am-url/--prom-url")
+	fs.StringVar(&f.http.TLS.CertFile, "cert-file", "", "Client certificate for --am-url/--prom-url")
+	fs.StringVar(&f.http.TLS.KeyFile, "key-file", "", "Client key for --am-url/--prom-url")
+	fs.BoolVar(&f.http.TLS.InsecureSkipVerify, "insecure-skip-verify", false, "Don't verify the server certificate of --am-url/--prom-url")
+	fs.StringVar(&f.http.ProxyURL, "proxy-url", "", "HTTP proxy for --am-url/--prom-url (default: $HTTPS_PROXY)")
+	return f
+}
+
+// endpointOverride is what the endpoint flags of one tier ask for: the
+// command line, or the environment and profile through config.Apply
+type endpointOverride struct {
+	EndpointConfig
+	source string
+}
+
+// alertmanagerOverride returns the Alertmanager flags given on the command
+// line, or with preset those set by env or profile; nil when there are none.
+// Fields of flags that weren't given stay empty.
+func (f *endpointFlags) alertmanagerOverride(preset bool) *endpointOverride {
+	var o *endpointOverride
+	values := map[string]*string{"am-url": &f.amURL, "n": &f.namespace, "svc": &f.service, "port": &f.port}
+	f.visit(preset, func(name, source string) {
+		if v, ok := values[name]; !ok || *v == "" {
+			return
+		}
+		if o == nil {
+			o = &endpointOverride{source: source}
+		} else if !strings.Contains(o.source, source) {
+			o.source += ", " + source
+		}
+		switch name {
+		case "am-url":
+			o.URL = f.amURL
+			o.HTTPConfig = f.httpConfig()
+		case "n":
+			o.Namespace = f.namespace
+		case "svc":
+			o.Service = f.service
+		case "port":
+			o.Port = f.port
+		}
+	})
+	return o
+}
+
+// prometheusOverride returns --prom-url of the tier, or nil when it wasn't
+// given there
+func (f *endpointFlags) prometheusOverride(preset bool) *endpointOverride {
+	var o *endpointOverride
+	f.visit(preset, func(name, source string) {
+		if name == "prom-url" && f.promURL != "" {
+			o = &endpointOverride{EndpointConfig: EndpointConfig{URL: f.promURL, HTTPConfig: f.httpConfig()}, source: source}
+		}
+	})
+	return o
+}
+
+// visit calls fn for every flag given on the command line, or with preset
+// for every flag config.Apply set from env or profile
+func (f *endpointFlags) visit(preset bool, fn func(name, source string)) {
+	if !preset {
+		f.fs.Visit(func(fl *flag.Flag) { fn(fl.Name, "flag") })
+		return
+	}
+	f.fs.VisitAll(func(fl *flag.Flag) {
+		if source, ok := config.Preset(f.fs, fl.Name); ok {
+			fn(fl.Name, source)
+		}
+	})
+}
+
+// httpConfig adds the bearer token from the environment to the flags
+func (f *endpointFlags) httpConfig() HTTPConfig {
+	c := f.http
+	if c.BearerTokenFile == "" {
+		c.BearerTokenEnv = "GOD_BEARER_TOKEN"
+	}
+	return c
+}
+
+// directName names the target of a command run without target flags when
+// everything it needs is reached by URL, so no kube context is required
+func (f *endpointFlags) directName() string {
+	raw := f.amURL
+	if raw == "" {
+		raw = f.promURL
+	}
+	if raw == "" || (f.amURL == "" && f.hasAlertmanagerFlags()) {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	return u.Host
+}
+
+func (f *endpointFlags) hasAlertmanagerFlags() bool {
+	return f.fs.Lookup("am-url") != nil
+}
+
+// --- Resolution ---
+
+// targetEndpoints caches what was resolved for one target
+type targetEndpoints struct {
+	once     sync.Once
+	services []kubeService
+	err      error
+	resolved map[string]Endpoint
+}
+
+// endpointResolver picks the Alertmanager and Prometheus services of each
+// target: flags > inventory host > env and profile > config > discovery >
+// defaults
+type endpointResolver struct {
+	flags   map[string]*endpointOverride
+	presets map[string]*endpointOverride
+	rules   []EndpointRule
+	// directName is set when the command needs no kube context at all
+	directName string
+
+	mu      sync.Mutex
+	targets map[string]*targetEndpoints
+}
+
+var endpoints = &endpointResolver{targets: map[string]*targetEndpoints{}}
+
+// configureEndpoints sets up endpoint resolution for a command
+func configureEndpoints(f *endpointFlags) {
+	cfg, err := loadAlertConfig()
+	if err != nil {
+		fmt.Printf("❌ Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+	for _, r := range cfg.Endpoints {
+		if _, err := path.Match(r.Match, ""); err != nil || r.Match == "" {
+			fmt.Printf("❌ Error: Invalid endpoints match '%s' in %s\n", r.Match, configPath())
+			os.Exit(1)
+		}
+	}
+
+	endpoints.rules = cfg.Endpoints
+	endpoints.flags = map[string]*endpointOverride{
+		alertmanagerComponent.name: f.alertmanagerOverride(false),
+		prometheusComponent.name:   f.prometheusOverride(false),
+	}
+	endpoints.presets = map[string]*endpointOverride{
+		alertmanagerComponent.name: f.alertmanagerOverride(true),
+		prometheusComponent.name:   f.prometheusOverride(true),
+	}
+	endpoints.directName = f.directName()
+}
+
+// alertmanagerEndpoint returns the Alertmanager service of a target
+func alertmanagerEndpoint(t Target) Endpoint {
+	return endpoints.resolve(t, alertmanagerComponent)
+}
+
+// prometheusEndpoint returns the Prometheus service of a target
+func prometheusEndpoint(t Target) Endpoint {
+	return endpoints.resolve(t, prometheusComponent)
+}
+
+func (r *endpointResolver) entry(t Target) *targetEndpoints {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := t.Kind() + "|" + t.Name
+	te, ok := r.targets[key]
+	if !ok {
+		te = &targetEndpoints{resolved: map[string]Endpoint{}}
+		r.targets[key] = te
+	}
+	return te
+}
+
+// resolve picks the endpoint once per target and component and reports the
+// choice on stderr, so it is never a surprise which service was queried
+func (r *endpointResolver) resolve(t Target, c component) Endpoint {
+	te := r.entry(t)
+
+	r.mu.Lock()
+	e, ok := te.resolved[c.name]
+	r.mu.Unlock()
+	if ok {
+		return e
+	}
+
+	e = r.choose(t, c, te)
+
+	r.mu.Lock()
+	if prev, ok := te.resolved[c.name]; ok {
+		r.mu.Unlock()
+		return prev
+	}
+	te.resolved[c.name] = e
+	r.mu.Unlock()
+
+	fmt.Fprintf(os.Stderr, "   📡 %s for %s: %s\n", c.name, t.Name, e)
+	return e
+}
+
+func (r *endpointResolver) choose(t Target, c component, te *targetEndpoints) Endpoint {
+	if o := r.flags[c.name]; o != nil {
+		return c.complete(*o, func() Endpoint { return r.chooseUnflagged(t, c, te) })
+	}
+	return r.chooseUnflagged(t, c, te)
+}
+
+// chooseUnflagged picks the endpoint from the inventory, the env and
+// profile, config, discovery or the defaults
+func (r *endpointResolver) chooseUnflagged(t Target, c component, te *targetEndpoints) Endpoint {
+	if t.Namespace != "" || (t.Service != "" && c.name == alertmanagerComponent.name) {
+		override := EndpointConfig{Namespace: t.Namespace}
+		if c.name == alertmanagerComponent.name {
+			override.Service = t.Service
+		}
+		return c.fill(override, "", "inventory")
+	}
+	if o := r.presets[c.name]; o != nil {
+		return c.complete(*o, func() Endpoint { return r.chooseConfigured(t, c, te) })
+	}
+	return r.chooseConfigured(t, c, te)
+}
+
+// chooseConfigured picks the endpoint from config, discovery or the defaults
+func (r *endpointResolver) chooseConfigured(t Target, c component, te *targetEndpoints) Endpoint {
+	for _, rule := range r.rules {
+		if ok, _ := path.Match(rule.Match, t.Name); !ok {
+			continue
+		}
+		override := rule.Alertmanager
+		if c.name == prometheusComponent.name {
+			override = rule.Prometheus
+		}
+		if override != nil {
+			return c.fill(*override, "", "config "+rule.Match)
+		}
+	}
+
+	te.once.Do(func() { te.services, te.err = discoverServices(t) })
+	if te.err == nil {
+		if e, ok := c.pick(te.services, defaultNamespace(t)); ok {
+			return e
+		}
+	}
+
+	// Nothing found: Prometheus is assumed next to Alertmanager
+	ns := defaultNamespace(t)
+	if c.name == prometheusComponent.name {
+		ns = r.resolve(t, alertmanagerComponent).Namespace
+	}
+	reason := "default, nothing discovered"
+	if te.err != nil {
+		reason = "default, discovery failed"
+	}
+	return c.fill(EndpointConfig{}, ns, reason)
+}
+
+// complete fills the fields an override leaves out, e.g. only --port was
+// given, from the endpoint the lower tiers pick
+func (c component) complete(o endpointOverride, rest func() Endpoint) Endpoint {
+	if o.URL != "" || o.Namespace != "" && o.Service != "" && o.Port != "" {
+		return c.fill(o.EndpointConfig, "", o.source)
+	}
+	base := rest()
+	if base.Direct() {
+		return c.fill(o.EndpointConfig, "", o.source)
+	}
+	if o.Namespace == "" {
+		o.Namespace = base.Namespace
+	}
+	if o.Service == "" {
+		o.Service = base.Service
+	}
+	if o.Port == "" {
+		o.Port = base.Port
+	}
+	return c.fill(o.EndpointConfig, "", o.source+", rest "+base.Source)
+}
+
+// fill completes an override with the component defaults
+func (c component) fill(o EndpointConfig, namespace, source string) Endpoint {
+	if o.URL != "" {
+		return Endpoint{URL: strings.TrimRight(o.URL, "/"), HTTP: o.HTTPConfig, Source: source}
+	}
+	e := Endpoint{
+		Namespace: o.Namespace,
+		Service:   strings.TrimPrefix(o.Service, "svc/"),
+		Port:      o.Port,
+		Source:    source,
+	}
+	if e.Namespace == "" {
+		e.Namespace = namespace
+	}
+	if e.Namespace == "" {
+		e.Namespace = "monitoring"
+	}
+	if e.Service == "" {
+		e.Service = c.defaultService
+	}
+	if e.Port == "" {
+		e.Port = c.defaultPort
+	}
+	return e
+}
+
+// --- Discovery ---
+
+// kubeService is the part of a Service object discovery looks at
+type kubeService struct {
+	Metadata struct {
+		Name      string            `json:"name"`
+		Namespace string            `json:"namespace"`
+		Labels    map[string]string `json:"labels"`
+	} `json:"metadata"`
+	Spec struct {
+		Ports []struct {
+			Name string `json:"name"`
+			Port int    `json:"port"`
+		} `json:"ports"`
+	} `json:"spec"`
+}
+
+// discoverServices lists every service of the target in one call, which is
+// enough to find both Alertmanager and Prometheus
+func discoverServices(t Target) ([]kubeService, error) {
+	output, err := runCommand(t, "kubectl get services --all-namespaces -o json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list services: %v", err)
+	}
+
+	var list struct {
+		Items []kubeService `json:"items"`
+	}
+	if err := json.Unmarshal(extractJSON(output, '{', '}'), &list); err != nil {
+		return nil, fmt.Errorf("failed to parse services: %v", err)
+	}
+	return list.Items, nil
+}
+
+// defaultNamespace is where the monitoring stack usually lives: the
+// LinuxAid stack's own namespace on standalone servers, monitoring elsewhere
+func defaultNamespace(t Target) string {
+	if t.IsSSH() {
+		return "monitoring-linuxaid"
+	}
+	return "monitoring"
+}
+
+// pick returns the best matching service: by selector preference, then the
+// preferred namespace, then by namespace and name so the choice is stable
+func (c component) pick(services []kubeService, preferNs string) (Endpoint, bool) {
+	for _, sel := range c.selectors {
+		var matches []kubeService
+		for _, svc := range services {
+			if svc.Metadata.Labels[sel[0]] == sel[1] && len(svc.Spec.Ports) > 0 {
+				matches = append(matches, svc)
+			}
+		}
+		if len(matches) == 0 {
+			continue
+		}
+
+		sort.Slice(matches, func(i, j int) bool {
+			if pi, pj := matches[i].Metadata.Namespace == preferNs, matches[j].Metadata.Namespace == preferNs; pi != pj {
+				return pi
+			}
+			if matches[i].Metadata.Namespace != matches[j].Metadata.Namespace {
+				return matches[i].Metadata.Namespace < matches[j].Metadata.Namespace
+			}
+			return matches[i].Metadata.Name < matches[j].Metadata.Name
+		})
+
+		svc := matches[0]
+		source := "discovered " + sel[0] + "=" + sel[1]
+		if len(matches) > 1 {
+			source += fmt.Sprintf(", 1 of %d", len(matches))
+		}
+		return Endpoint{
+			Namespace: svc.Metadata.Namespace,
+			Service:   svc.Metadata.Name,
+			Port:      webPort(svc),
+			Source:    source,
+		}, true
+	}
+	return Endpoint{}, false
+}
+
+// webPort returns the port serving the HTTP API of a service
+func webPort(svc kubeService) string {
+	for _, name := range webPortNames {
+		for _, p := range svc.Spec.Ports {
+			if p.Name == name {
+				return fmt.Sprint(p.Port)
+			}
+		}
+	}
+	return fmt.Sprint(svc.Spec.Ports[0].Port)
+}
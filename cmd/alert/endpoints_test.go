@@ -0,0 +1,89 @@
+package alert
+
+import (
+	"flag"
+	"god/cmd/config"
+	"os"
+	"testing"
+)
+
+// The tests never reach discovery: every target is matched by the config
+// rule, which stands in for whatever the lower tiers would pick
+func TestEndpointPrecedence(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	rules := []EndpointRule{{
+		Match:        "*",
+		Alertmanager: &EndpointConfig{Namespace: "cfg-ns", Service: "cfg-am", Port: "9000"},
+		Prometheus:   &EndpointConfig{Namespace: "cfg-ns"},
+	}}
+	host := Target{Name: "web-01", Server: "web-01", Namespace: "inv-ns", Service: "inv-am"}
+	cluster := Target{Name: "prod"}
+
+	tests := []struct {
+		name   string
+		env    map[string]string
+		args   []string
+		target Target
+		want   string
+	}{
+		{"config", nil, nil, cluster, "cfg-ns/cfg-am:9000 (config *)"},
+		{"inventory over config", nil, nil, host, "inv-ns/inv-am:9093 (inventory)"},
+		{"flag over inventory", nil, []string{"-n", "cli-ns", "--svc", "cli-am", "--port", "1"}, host, "cli-ns/cli-am:1 (flag)"},
+		{"partial flag, rest from inventory", nil, []string{"--port", "1"}, host, "inv-ns/inv-am:1 (flag, rest inventory)"},
+		{"env over config", map[string]string{"GOD_ALERT_SCAN_SVC": "env-am"}, nil, cluster, "cfg-ns/env-am:9000 (GOD_ALERT_SCAN_SVC, rest config *)"},
+		{"inventory over env", map[string]string{"GOD_ALERT_N": "env-ns", "GOD_ALERT_SCAN_SVC": "env-am"}, nil, host, "inv-ns/inv-am:9093 (inventory)"},
+		{"flag over env", map[string]string{"GOD_ALERT_N": "env-ns"}, []string{"-n", "cli-ns"}, cluster, "cli-ns/cfg-am:9000 (flag, rest config *)"},
+		{"env URL over config", map[string]string{"GOD_ALERT_AM_URL": "https://am.example"}, nil, cluster, "https://am.example (GOD_ALERT_AM_URL)"},
+	}
+	for _, tt := range tests {
+		for k, v := range tt.env {
+			t.Setenv(k, v)
+		}
+		fs := flag.NewFlagSet("scan", flag.ContinueOnError)
+		f := addEndpointFlags(fs, true)
+		config.Apply(fs, "alert")
+		if err := fs.Parse(tt.args); err != nil {
+			t.Fatal(err)
+		}
+		for k := range tt.env {
+			os.Unsetenv(k)
+		}
+
+		r := &endpointResolver{
+			flags:   map[string]*endpointOverride{alertmanagerComponent.name: f.alertmanagerOverride(false)},
+			presets: map[string]*endpointOverride{alertmanagerComponent.name: f.alertmanagerOverride(true)},
+			rules:   rules,
+			targets: map[string]*targetEndpoints{},
+		}
+		if got := r.choose(tt.target, alertmanagerComponent, r.entry(tt.target)).String(); got != tt.want {
+			t.Errorf("%s: got %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPickPrefersNamespace(t *testing.T) {
+	svc := func(ns string) kubeService {
+		var s kubeService
+		s.Metadata.Namespace, s.Metadata.Name = ns, "alertmanager-operated"
+		s.Metadata.Labels = map[string]string{"operated-alertmanager": "true"}
+		s.Spec.Ports = append(s.Spec.Ports, struct {
+			Name string `json:"name"`
+			Port int    `json:"port"`
+		}{"web", 9093})
+		return s
+	}
+	services := []kubeService{svc("monitoring-linuxaid"), svc("monitoring")}
+
+	for _, tt := range []struct {
+		target Target
+		want   string
+	}{
+		{Target{Name: "prod"}, "monitoring"},
+		{Target{Name: "web-01", Server: "web-01"}, "monitoring-linuxaid"},
+	} {
+		e, ok := alertmanagerComponent.pick(services, defaultNamespace(tt.target))
+		if !ok || e.Namespace != tt.want {
+			t.Errorf("%s: picked %s, want namespace %s", tt.target.Name, e, tt.want)
+		}
+	}
+}
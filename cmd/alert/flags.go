@@ -0,0 +1,39 @@
+package alert
+
+import (
+	"flag"
+	"strings"
+)
+
+// stringList is a flag that may be given several times
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// Reset drops the values, so config.Apply can let the command line replace
+// the ones a profile set
+func (s *stringList) Reset() {
+	*s = nil
+}
+
+// parseInterleaved parses flags that may come before, between or after the
+// positional arguments and returns the positional ones, e.g.
+// god alert rules lint ./rules --require-label team
+func parseInterleaved(set *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		set.Parse(args)
+		if set.NArg() == 0 {
+			return positional
+		}
+		positional = append(positional, set.Arg(0))
+		args = set.Args()[1:]
+	}
+}
@@ -0,0 +1,191 @@
+package alert
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// groupByModes lists the accepted values for `scan --group-by`
+var groupByModes = []string{"alertname", "severity", "namespace", "cluster"}
+
+// maxListedClusters caps how many cluster names are printed per grouped alert
+const maxListedClusters = 8
+
+// ClusterAlerts holds the alerts fetched from a single cluster or server.
+// Err is set when they could not be fetched.
+type ClusterAlerts struct {
+	Cluster string
+	Alerts  []Alert
+	Err     string
+}
+
+// alertGroup is one bucket of the grouped view (e.g. all "critical" alerts)
+type alertGroup struct {
+	Key      string
+	Count    int
+	Clusters map[string]bool
+	Entries  map[string]*groupEntry
+}
+
+// groupEntry is a single alertname inside a group, merged across clusters
+type groupEntry struct {
+	Name     string
+	Count    int
+	Clusters map[string]bool
+	Earliest time.Time
+}
+
+func validGroupBy(by string) bool {
+	for _, m := range groupByModes {
+		if m == by {
+			return true
+		}
+	}
+	return false
+}
+
+// groupKey returns the value an alert is bucketed under for the given mode
+func groupKey(alert Alert, cluster, by string) string {
+	var key string
+	switch by {
+	case "cluster":
+		key = cluster
+	case "namespace":
+		key = alert.Labels["namespace"]
+		if key == "" {
+			key = "global"
+		}
+	default:
+		key = alert.Labels[by]
+	}
+	if key == "" {
+		key = "none"
+	}
+	return key
+}
+
+// groupAlerts merges the alerts of several clusters into buckets keyed by the
+// --group-by mode, sorted by alert count (largest incident first)
+func groupAlerts(results []ClusterAlerts, by string) []*alertGroup {
+	groups := make(map[string]*alertGroup)
+
+	for _, res := range results {
+		for _, alert := range res.Alerts {
+			key := groupKey(alert, res.Cluster, by)
+			g, ok := groups[key]
+			if !ok {
+				g = &alertGroup{Key: key, Clusters: map[string]bool{}, Entries: map[string]*groupEntry{}}
+				groups[key] = g
+			}
+			g.Count++
+			g.Clusters[res.Cluster] = true
+
+			name := alert.Labels["alertname"]
+			e, ok := g.Entries[name]
+			if !ok {
+				e = &groupEntry{Name: name, Clusters: map[string]bool{}}
+				g.Entries[name] = e
+			}
+			e.Count++
+			e.Clusters[res.Cluster] = true
+			if t, err := time.Parse(time.RFC3339, alert.StartsAt); err == nil {
+				if e.Earliest.IsZero() || t.Before(e.Earliest) {
+					e.Earliest = t
+				}
+			}
+		}
+	}
+
+	sorted := make([]*alertGroup, 0, len(groups))
+	for _, g := range groups {
+		sorted = append(sorted, g)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Count != sorted[j].Count {
+			return sorted[i].Count > sorted[j].Count
+		}
+		return sorted[i].Key < sorted[j].Key
+	})
+	return sorted
+}
+
+func printGroupedAlerts(all []ClusterAlerts, by string) {
+	var results, failed []ClusterAlerts
+	total := 0
+	for _, res := range all {
+		if res.Err != "" {
+			failed = append(failed, res)
+			continue
+		}
+		results = append(results, res)
+		total += len(res.Alerts)
+	}
+
+	fmt.Printf("\n==================================================\n")
+	if total == 0 {
+		fmt.Printf("✅ No active alerts across %d clusters.\n", len(results))
+		printFailedClusters(failed)
+		return
+	}
+	fmt.Printf("📊 %d alerts across %d clusters, grouped by %s\n", total, len(results), by)
+	printFailedClusters(failed)
+
+	for _, g := range groupAlerts(results, by) {
+		// Grouping by alertname already yields one entry per group,
+		// so a header line would only repeat the alert name.
+		if by != "alertname" {
+			fmt.Printf("\n%s (%d alerts, %d clusters)\n", g.Key, g.Count, len(g.Clusters))
+		}
+		for _, e := range sortedEntries(g) {
+			printGroupEntry(e)
+		}
+	}
+	fmt.Println("")
+}
+
+// printFailedClusters lists the clusters left out of the counts because
+// their alerts could not be fetched
+func printFailedClusters(failed []ClusterAlerts) {
+	if len(failed) == 0 {
+		return
+	}
+	fmt.Printf("⚠️  %d clusters could not be scanned:\n", len(failed))
+	for _, res := range failed {
+		// The full error was printed while scanning
+		fmt.Printf("   %s: %s\n", res.Cluster, strings.SplitN(res.Err, "\n", 2)[0])
+	}
+}
+
+func sortedEntries(g *alertGroup) []*groupEntry {
+	entries := make([]*groupEntry, 0, len(g.Entries))
+	for _, e := range g.Entries {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if len(entries[i].Clusters) != len(entries[j].Clusters) {
+			return len(entries[i].Clusters) > len(entries[j].Clusters)
+		}
+		return entries[i].Name < entries[j].Name
+	})
+	return entries
+}
+
+func printGroupEntry(e *groupEntry) {
+	since := "unknown"
+	if !e.Earliest.IsZero() {
+		since = e.Earliest.UTC().Format("2006-01-02 15:04 UTC")
+	}
+	fmt.Printf("   🔴 %-35s x%-3d %3d clusters  since %s\n", e.Name, e.Count, len(e.Clusters), since)
+
+	names := make([]string, 0, len(e.Clusters))
+	for c := range e.Clusters {
+		names = append(names, c)
+	}
+	sort.Strings(names)
+	if len(names) > maxListedClusters {
+		names = append(names[:maxListedClusters], fmt.Sprintf("+%d more", len(e.Clusters)-maxListedClusters))
+	}
+	fmt.Printf("      ↳ %s\n", strings.Join(names, ", "))
+}
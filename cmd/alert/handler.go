@@ -1,10 +1,30 @@
 package alert
 
 import (
+	"flag"
 	"fmt"
 	"os"
 )
 
+// Commands declares the flags of each command, so god config can find the
+// flags a profile may set without running anything
+var Commands = map[string]func() *flag.FlagSet{
+	"list":       func() *flag.FlagSet { return newListFlags().FlagSet },
+	"scan":       func() *flag.FlagSet { return newScanFlags().FlagSet },
+	"details":    func() *flag.FlagSet { return newDetailsFlags().FlagSet },
+	"watch":      func() *flag.FlagSet { return newWatchFlags().FlagSet },
+	"query":      func() *flag.FlagSet { return newQueryFlags().FlagSet },
+	"open":       func() *flag.FlagSet { return newOpenFlags().FlagSet },
+	"history":    func() *flag.FlagSet { return newHistoryFlags().FlagSet },
+	"diff":       func() *flag.FlagSet { return newDiffFlags().FlagSet },
+	"serve":      func() *flag.FlagSet { return newServeFlags().FlagSet },
+	"routes":     func() *flag.FlagSet { return newRoutesFlags().FlagSet },
+	"rules lint": func() *flag.FlagSet { return newRulesLintFlags().FlagSet },
+	"rules test": func() *flag.FlagSet { return newRulesTestFlags().FlagSet },
+	"tui":        func() *flag.FlagSet { return newTuiFlags().FlagSet },
+	"handoff":    func() *flag.FlagSet { return newHandoffFlags().FlagSet },
+}
+
 func Handle(args []string) {
 	if len(args) < 1 {
 		printHelp()
@@ -18,6 +38,26 @@ func Handle(args []string) {
 		runScan(args[1:])
 	case "details":
 		runDetails(args[1:]) // <--- Add this
+	case "watch":
+		runWatch(args[1:])
+	case "query":
+		runQuery(args[1:])
+	case "open":
+		runOpen(args[1:])
+	case "history":
+		runHistory(args[1:])
+	case "diff":
+		runDiff(args[1:])
+	case "serve":
+		runServe(args[1:])
+	case "rules":
+		runRules(args[1:])
+	case "routes":
+		runRoutes(args[1:])
+	case "tui":
+		runTUI(args[1:])
+	case "handoff":
+		runHandoff(args[1:])
 	case "help":
 		printHelp()
 	default:
@@ -31,9 +71,81 @@ func printHelp() {
 	fmt.Println("Usage: god alert <command> [flags]")
 	fmt.Println("\nCommands:")
 	fmt.Println("  list     List alerts on current cluster")
-	fmt.Println("  scan     Scan multiple Teleport clusters or a specific SSH server")
+	fmt.Println("  open     Print or launch the runbook of an alert: god alert open <index|fingerprint>")
+	fmt.Println("  scan     Scan Teleport clusters, kubeconfig contexts and/or an SSH server")
 	fmt.Println("  details  Scan and run diagnostics on matching alerts")
+	fmt.Println("  watch    Poll alerts and print only what changed")
+	fmt.Println("  query    Run a PromQL query: god alert query '<promql>'")
+	fmt.Println("  history  Firing history of alerts from the Prometheus ALERTS metric")
+	fmt.Println("  diff     Compare the latest saved scan with an earlier one")
+	fmt.Println("  serve    Scan periodically and expose the results as Prometheus metrics")
+	fmt.Println("  routes   Show the Alertmanager routing tree; routes test <name=value...> shows where labels go")
+	fmt.Println("  rules    Check Prometheus alerting rules: god alert rules lint <paths> | test <test files>")
+	fmt.Println("  handoff  Markdown summary of a shift: still firing, resolved, newly silenced, noisiest")
+	fmt.Println("  tui      Interactive dashboard: browse, filter, silence and diagnose alerts across clusters")
 	fmt.Println("\nFlags (scan & details):")
-	fmt.Println("  --filter <name>   Filter clusters via Teleport")
+	fmt.Println("  --filter <regex>   Teleport clusters whose name matches")
+	fmt.Println("  --labels <list>    Teleport clusters with these labels, e.g. env=prod,region=eu")
+	fmt.Println("  --refresh-clusters List Teleport clusters again instead of using the cached list")
+	fmt.Println("  --contexts <glob>  Kubeconfig contexts, e.g. 'prod-*' (combines with --filter)")
 	fmt.Println("  --server <user@ip> Direct SSH connection to Linux server")
+	fmt.Println("  --group <name>     Servers of an inventory group ('all' for every host)")
+	fmt.Println("  --inventory <file> Inventory file (default ~/.config/god/inventory.yaml)")
+	fmt.Println("  -n, --svc, --port  Alertmanager service (default: discovered per target)")
+	fmt.Println("  --am-url <url>     Reach Alertmanager by URL (also --prom-url; auth: --bearer-token-file,")
+	fmt.Println("                     --ca-file, --cert-file, --key-file, --proxy-url)")
+	fmt.Println("\nFlags (list & scan):")
+	fmt.Println("  --details          Show summary, description, runbook and dashboard links")
+	fmt.Println("  --no-color         Disable severity colors (also NO_COLOR; off when not a terminal)")
+	fmt.Println("                     Exit code 3 if a critical alert fires, 2 for warning, 1 on errors;")
+	fmt.Println("                     scan exits 1 when a cluster fails and no alert warrants 2 or 3")
+	fmt.Println("\nFlags (open):")
+	fmt.Println("  --dashboard        Use the dashboard link (with the alert's time range) instead of the runbook")
+	fmt.Println("  --browser          Launch the link in the default browser")
+	fmt.Println("\nFlags (scan):")
+	fmt.Println("  --group-by <mode>  Merge results across clusters: alertname|severity|namespace|cluster")
+	fmt.Println("  --save             Persist the run for `god alert diff`")
+	fmt.Println("  --keep <n>         Saved runs to keep per --filter (default 50)")
+	fmt.Println("  --notify <sink>    Send a digest when the alert set changed: a sink name from config,")
+	fmt.Println("                     webhook=<url>, slack=<url> or smtp=smtp://host:port?from=..&to=..")
+	fmt.Println("  --notify-always    Send the digest even if nothing changed")
+	fmt.Println("\nFlags (diff):")
+	fmt.Println("  --since <id|dur>   Baseline run ID or age, e.g. 8h (default: previous run with the same --filter)")
+	fmt.Println("  --list             List saved runs")
+	fmt.Println("  --filter, --contexts, --server, --group ...")
+	fmt.Println("                     Compare runs saved with these target flags (default: those of the latest run)")
+	fmt.Println("\nFlags (details):")
+	fmt.Println("  --rules-dir <dir>  YAML diagnostic rules (default ~/.config/god/rules.d)")
+	fmt.Println("  --parallel <n>     Maximum diagnoses running at once per cluster (default 4)")
+	fmt.Println("  --report <file>    Write an incident report (.md or .html) with outputs and commands")
+	fmt.Println("  --remediate        Offer allowlisted remediation actions, each confirmed with 'yes'")
+	fmt.Println("  --dry-run          Only show the remediation commands")
+	fmt.Println("\nFlags (query):")
+	fmt.Println("  --range <dur>      Range query window, rendered as sparklines (e.g. 1h)")
+	fmt.Println("  --step <dur>       Range query resolution (default 1m)")
+	fmt.Println("  --json             Print the raw result as JSON")
+	fmt.Println("\nFlags (history):")
+	fmt.Println("  --alert <name>     Alert to report on (default: all)")
+	fmt.Println("  --since <dur>      Window to look back over (default 7d)")
+	fmt.Println("  --aggregate        Merge identical alerts across --filter clusters")
+	fmt.Println("\nFlags (serve):")
+	fmt.Println("  --listen <addr>    Address to serve /metrics on (default :9900)")
+	fmt.Println("  --interval <dur>   Time between scans (default 1m)")
+	fmt.Println("\nFlags (rules lint):")
+	fmt.Println("  --require-label <list>       Labels every alert must have (default severity)")
+	fmt.Println("  --require-annotation <list>  Annotations every alert must have (default summary,runbook_url)")
+	fmt.Println("\nFlags (rules test):")
+	fmt.Println("  --run <regex>      Only run test groups whose name matches the expression")
+	fmt.Println("\nFlags (handoff):")
+	fmt.Println("  --since <dur>      Length of the shift (default 12h)")
+	fmt.Println("  --top <n>          Rows in the noisiest alerts and clusters tables (default 5)")
+	fmt.Println("  --output <file>    Write the Markdown to a file instead of stdout")
+	fmt.Println("\nFlags (tui):")
+	fmt.Println("  --interval <dur>   Time between automatic refreshes (default 1m, 0 for manual)")
+	fmt.Println("  --match <list>     Initial alert filter, e.g. 'severity=\"critical\",namespace=~\"kube-.*\"'")
+	fmt.Println("  --rules-dir <dir>  YAML diagnostic rules run with d (default ~/.config/god/rules.d)")
+	fmt.Println("\nFlags (watch):")
+	fmt.Println("  --interval <dur>   Time between polls (default 30s)")
+	fmt.Println("  --clear            Full-screen refreshed view on a TTY")
+	fmt.Println("  --exec <cmd>       Run a shell command per change with the event JSON on stdin")
 }
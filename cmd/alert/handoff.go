@@ -0,0 +1,338 @@
+package alert
+
+import (
+	"flag"
+	"fmt"
+	"god/cmd/config"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Handoff is the shift summary written by `god alert handoff`
+type Handoff struct {
+	Start    time.Time
+	End      time.Time
+	Top      int
+	Clusters []*HandoffCluster
+}
+
+// HandoffCluster is what one target contributed. Each source is fetched on
+// its own, so an unreachable Prometheus still leaves the current alerts.
+type HandoffCluster struct {
+	Name     string
+	Alerts   []Alert
+	History  []historySeries
+	Silences []Silence
+	Errors   []string
+}
+
+// handoffAlert is a current alert together with its cluster
+type handoffAlert struct {
+	Cluster string
+	Alert   Alert
+}
+
+// handoffCount is one row of the noisiest alerts and clusters tables
+type handoffCount struct {
+	Name     string
+	Firings  int
+	Firing   time.Duration
+	Clusters map[string]bool
+	Alerts   map[string]bool
+	Current  int
+}
+
+// handoffFlags holds the flags of god alert handoff
+type handoffFlags struct {
+	*flag.FlagSet
+	targets   *targetFlags
+	endpoints *endpointFlags
+	since     *string
+	step      *time.Duration
+	top       *int
+	output    *string
+}
+
+func newHandoffFlags() *handoffFlags {
+	f := &handoffFlags{FlagSet: flag.NewFlagSet("handoff", flag.ExitOnError)}
+	f.targets = addTargetFlags(f.FlagSet)
+	f.endpoints = addEndpointFlags(f.FlagSet, true)
+	f.since = f.String("since", "12h", "Length of the shift to summarize (e.g. 8h, 12h, 1d)")
+	f.step = f.Duration("step", time.Minute, "Resolution of the ALERTS range query")
+	f.top = f.Int("top", 5, "Rows in the noisiest alerts and clusters tables")
+	f.output = f.String("output", "", "Write the Markdown to this file instead of stdout")
+	return f
+}
+
+func runHandoff(args []string) {
+	f := newHandoffFlags()
+	tf, ef, since, step, top, output := f.targets, f.endpoints, f.since, f.step, f.top, f.output
+	config.Apply(f.FlagSet, "alert")
+	f.Parse(args)
+	configureEndpoints(ef)
+	configureDisplay(true)
+
+	window, err := ParseLongDuration(*since)
+	if err != nil {
+		fmt.Printf("❌ Error: Invalid --since: %v\n", err)
+		os.Exit(1)
+	}
+	if minStep := window / maxRangePoints; *step < minStep {
+		*step = minStep.Round(time.Second)
+	}
+
+	h := &Handoff{End: time.Now(), Top: *top}
+	h.Start = h.End.Add(-window)
+
+	targets := serveTargets(tf)
+	defer releaseTargets(targets)
+	defer releaseOnSignal(targets)()
+	for _, t := range targets {
+		// Progress goes to stderr so stdout stays pure Markdown
+		fmt.Fprintf(os.Stderr, "🔄 Collecting %s...\n", t.Name)
+		c := &HandoffCluster{Name: t.Name}
+		h.Clusters = append(h.Clusters, c)
+		if err := t.Connect(); err != nil {
+			c.Errors = append(c.Errors, fmt.Sprintf("login failed: %v", err))
+			continue
+		}
+
+		if c.Alerts, err = FetchAlerts(t); err != nil {
+			c.Errors = append(c.Errors, firstLine(err.Error()))
+		}
+		if c.History, err = fetchFiringHistory(t, "", h.Start, h.End, *step); err != nil {
+			c.Errors = append(c.Errors, "history: "+firstLine(err.Error()))
+		}
+		if c.Silences, err = fetchSilences(t); err != nil {
+			c.Errors = append(c.Errors, "silences: "+firstLine(err.Error()))
+		}
+	}
+
+	if *output == "" {
+		h.writeMarkdown(os.Stdout)
+		return
+	}
+	out, err := os.Create(*output)
+	if err == nil {
+		err = h.writeMarkdown(out)
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		fmt.Printf("❌ Error writing %s: %v\n", *output, err)
+		os.Exit(1)
+	}
+	fmt.Printf("📝 Handoff written to %s\n", *output)
+}
+
+// --- Sections ---
+
+// stillFiring returns the current alerts of all clusters, most severe first
+func (h *Handoff) stillFiring() []handoffAlert {
+	var rows []handoffAlert
+	for _, c := range h.Clusters {
+		for _, a := range c.Alerts {
+			rows = append(rows, handoffAlert{Cluster: c.Name, Alert: a})
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		ri, rj := severityRank(rows[i].Alert.Labels["severity"]), severityRank(rows[j].Alert.Labels["severity"])
+		if ri != rj {
+			return ri < rj
+		}
+		return rows[i].Alert.StartsAt < rows[j].Alert.StartsAt
+	})
+	return rows
+}
+
+// resolved returns the label sets that fired during the shift and are no
+// longer firing, most recently resolved first
+func (h *Handoff) resolved() []historySeries {
+	var rows []historySeries
+	for _, c := range h.Clusters {
+		for _, s := range c.History {
+			if !s.ongoing() && len(s.Intervals) > 0 {
+				rows = append(rows, s)
+			}
+		}
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		return lastResolved(rows[i]).After(lastResolved(rows[j]))
+	})
+	return rows
+}
+
+func lastResolved(s historySeries) time.Time {
+	return s.Intervals[len(s.Intervals)-1].End
+}
+
+// newlySilenced returns the silences created during the shift that still
+// apply, with the cluster they were created on
+func (h *Handoff) newlySilenced() (silences []Silence, clusters []string) {
+	for _, c := range h.Clusters {
+		for _, s := range c.Silences {
+			if state := s.State(); state == "expired" || s.StartsAt.Before(h.Start) {
+				continue
+			}
+			silences = append(silences, s)
+			clusters = append(clusters, c.Name)
+		}
+	}
+	return silences, clusters
+}
+
+// noisiestAlerts counts firings per alert name across all clusters
+func (h *Handoff) noisiestAlerts() []*handoffCount {
+	counts := make(map[string]*handoffCount)
+	for _, c := range h.Clusters {
+		for _, s := range c.History {
+			name := s.Labels["alertname"]
+			n, ok := counts[name]
+			if !ok {
+				n = &handoffCount{Name: name, Clusters: map[string]bool{}}
+				counts[name] = n
+			}
+			n.Firings += len(s.Intervals)
+			n.Firing += s.totalFiring()
+			n.Clusters[c.Name] = true
+		}
+	}
+	return sortedCounts(counts)
+}
+
+// noisiestClusters counts firings and distinct alerts per cluster
+func (h *Handoff) noisiestClusters() []*handoffCount {
+	counts := make(map[string]*handoffCount)
+	for _, c := range h.Clusters {
+		n := &handoffCount{Name: c.Name, Alerts: map[string]bool{}, Current: len(c.Alerts)}
+		for _, s := range c.History {
+			n.Firings += len(s.Intervals)
+			n.Firing += s.totalFiring()
+			n.Alerts[s.Labels["alertname"]] = true
+		}
+		if n.Firings > 0 || n.Current > 0 {
+			counts[c.Name] = n
+		}
+	}
+	return sortedCounts(counts)
+}
+
+// sortedCounts orders the noisiest first: most firings, then longest firing
+func sortedCounts(counts map[string]*handoffCount) []*handoffCount {
+	var rows []*handoffCount
+	for _, name := range sortedKeys(counts) {
+		rows = append(rows, counts[name])
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if rows[i].Firings != rows[j].Firings {
+			return rows[i].Firings > rows[j].Firings
+		}
+		return rows[i].Firing > rows[j].Firing
+	})
+	return rows
+}
+
+// --- Markdown ---
+
+func (h *Handoff) writeMarkdown(w io.Writer) error {
+	var b strings.Builder
+	firing := h.stillFiring()
+	resolved := h.resolved()
+	silences, silenceClusters := h.newlySilenced()
+
+	critical := 0
+	for _, r := range firing {
+		if alertLevel(r.Alert) == levelCritical {
+			critical++
+		}
+	}
+
+	fmt.Fprintf(&b, "# On-call handoff %s\n\n", h.End.Format("2006-01-02 15:04 MST"))
+	fmt.Fprintf(&b, "_Shift %s → %s (%s), %d clusters_\n\n", h.Start.Format("Jan 2 15:04"), h.End.Format("Jan 2 15:04"),
+		formatDuration(h.End.Sub(h.Start)), len(h.Clusters))
+	fmt.Fprintf(&b, "**%d still firing** (%d critical), **%d resolved** during the shift, **%d newly silenced**.\n",
+		len(firing), critical, len(resolved), len(silences))
+
+	var problems []string
+	for _, c := range h.Clusters {
+		for _, e := range c.Errors {
+			problems = append(problems, fmt.Sprintf("- %s: %s\n", c.Name, mdCell(e)))
+		}
+	}
+	if len(problems) > 0 {
+		b.WriteString("\n> ⚠️ Incomplete data:\n>\n")
+		for _, p := range problems {
+			b.WriteString("> " + p)
+		}
+	}
+
+	fmt.Fprintf(&b, "\n## 🔴 Still firing (%d)\n\n", len(firing))
+	if len(firing) == 0 {
+		b.WriteString("Nothing is firing.\n")
+	} else {
+		b.WriteString("| Severity | Alert | Cluster | Target | Firing for | Summary |\n| --- | --- | --- | --- | --- | --- |\n")
+		for _, r := range firing {
+			a := r.Alert
+			severity := a.Labels["severity"]
+			if severity == "" {
+				severity = "none"
+			}
+			since := ""
+			if start, err := time.Parse(time.RFC3339, a.StartsAt); err == nil {
+				since = formatDuration(h.End.Sub(start))
+			}
+			fmt.Fprintf(&b, "| %s %s | %s | %s | %s | %s | %s |\n", levelIcons[alertLevel(a)], mdCell(severity),
+				mdCell(a.Labels["alertname"]), mdCell(r.Cluster), mdCell(alertTarget(a)), since, mdCell(a.Annotations["summary"]))
+		}
+	}
+
+	fmt.Fprintf(&b, "\n## ✅ Resolved during the shift (%d)\n\n", len(resolved))
+	if len(resolved) == 0 {
+		b.WriteString("Nothing resolved.\n")
+	} else {
+		b.WriteString("| Alert | Cluster | Firings | Firing for | Last resolved |\n| --- | --- | --- | --- | --- |\n")
+		for _, s := range resolved {
+			fmt.Fprintf(&b, "| %s | %s | %d | %s | %s |\n", mdCell(s.title()), mdCell(strings.Join(s.Clusters, ", ")),
+				len(s.Intervals), formatDuration(s.totalFiring()), lastResolved(s).Format("Jan 2 15:04"))
+		}
+	}
+
+	fmt.Fprintf(&b, "\n## 🔕 Newly silenced (%d)\n\n", len(silences))
+	if len(silences) == 0 {
+		b.WriteString("No silences were created.\n")
+	} else {
+		b.WriteString("| Matchers | Cluster | By | Until | Comment |\n| --- | --- | --- | --- | --- |\n")
+		for i, s := range silences {
+			fmt.Fprintf(&b, "| `%s` | %s | %s | %s | %s |\n", mdCell(strings.ReplaceAll(s.String(), "`", "'")), mdCell(silenceClusters[i]),
+				mdCell(s.CreatedBy), s.EndsAt.Local().Format("Jan 2 15:04"), mdCell(s.Comment))
+		}
+	}
+
+	if alerts := h.noisiestAlerts(); len(alerts) > 0 {
+		b.WriteString("\n## 📢 Noisiest alerts\n\n| Alert | Firings | Firing for | Clusters |\n| --- | --- | --- | --- |\n")
+		for i, n := range alerts {
+			if h.Top > 0 && i >= h.Top {
+				break
+			}
+			fmt.Fprintf(&b, "| %s | %d | %s | %s |\n", mdCell(n.Name), n.Firings, formatDuration(n.Firing),
+				mdCell(strings.Join(sortedKeys(n.Clusters), ", ")))
+		}
+	}
+
+	if clusters := h.noisiestClusters(); len(clusters) > 0 {
+		b.WriteString("\n## 🌐 Noisiest clusters\n\n| Cluster | Firings | Distinct alerts | Still firing |\n| --- | --- | --- | --- |\n")
+		for i, n := range clusters {
+			if h.Top > 0 && i >= h.Top {
+				break
+			}
+			fmt.Fprintf(&b, "| %s | %d | %d | %d |\n", mdCell(n.Name), n.Firings, len(n.Alerts), n.Current)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,292 @@
+package alert
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"god/cmd/config"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// timelineWidth is the number of characters in a history timeline
+	timelineWidth = 60
+	// maxRangePoints stays below Prometheus' 11000 points per series limit
+	maxRangePoints = 10000
+)
+
+// firingInterval is one continuous period an alert was firing
+type firingInterval struct {
+	Start   time.Time
+	End     time.Time
+	Ongoing bool // still firing at the end of the window
+}
+
+// historySeries is the firing history of one alert label set on one cluster
+type historySeries struct {
+	Clusters  []string
+	Labels    map[string]string
+	Intervals []firingInterval
+}
+
+// historyFlags holds the flags of god alert history
+type historyFlags struct {
+	*flag.FlagSet
+	alertName *string
+	since     *string
+	step      *time.Duration
+	targets   *targetFlags
+	endpoints *endpointFlags
+	aggregate *bool
+	top       *int
+}
+
+func newHistoryFlags() *historyFlags {
+	f := &historyFlags{FlagSet: flag.NewFlagSet("history", flag.ExitOnError)}
+	f.alertName = f.String("alert", "", "Alert name to report on (default: all alerts)")
+	f.since = f.String("since", "7d", "How far back to look (e.g. 12h, 7d, 2w)")
+	f.step = f.Duration("step", time.Minute, "Resolution of the ALERTS range query")
+	f.targets = addTargetFlags(f.FlagSet)
+	f.endpoints = addEndpointFlags(f.FlagSet, false)
+	f.aggregate = f.Bool("aggregate", false, "Merge identical label sets across clusters into one report")
+	f.top = f.Int("top", 20, "Show at most this many label sets per report")
+	return f
+}
+
+func runHistory(args []string) {
+	f := newHistoryFlags()
+	alertName, since, step, tf, ef, aggregate, top := f.alertName, f.since, f.step, f.targets, f.endpoints, f.aggregate, f.top
+	config.Apply(f.FlagSet, "alert")
+	f.Parse(args)
+	configureEndpoints(ef)
+
+	window, err := ParseLongDuration(*since)
+	if err != nil {
+		fmt.Printf("❌ Error: Invalid --since: %v\n", err)
+		os.Exit(1)
+	}
+
+	end := time.Now()
+	start := end.Add(-window)
+	// Widen the step for long windows so we stay under the points limit
+	if minStep := window / maxRangePoints; *step < minStep {
+		*step = minStep.Round(time.Second)
+	}
+
+	var all []historySeries
+	forEachTarget(tf, func(t Target) {
+		name := t.Name
+		series, err := fetchFiringHistory(t, *alertName, start, end, *step)
+		if err != nil {
+			fmt.Printf("\n🌐 %s\n   ❌ %v\n", name, err)
+			return
+		}
+		if *aggregate {
+			all = append(all, series...)
+			return
+		}
+		fmt.Printf("\n🌐 %s\n", name)
+		printHistory(series, start, end, *top)
+	})
+
+	if *aggregate {
+		fmt.Printf("\n📊 Aggregated history since %s\n", start.Format("2006-01-02 15:04"))
+		printHistory(aggregateHistory(all), start, end, *top)
+	}
+}
+
+// fetchFiringHistory reconstructs firing intervals from the ALERTS metric
+func fetchFiringHistory(t Target, alertName string, start, end time.Time, step time.Duration) ([]historySeries, error) {
+	promQuery := `ALERTS{alertstate="firing"}`
+	if alertName != "" {
+		promQuery = fmt.Sprintf(`ALERTS{alertstate="firing", alertname=%q}`, alertName)
+	}
+
+	resp, err := queryPrometheusRange(context.Background(), t, promQuery, start, end, step)
+	if err != nil {
+		return nil, err
+	}
+
+	var series []historySeries
+	for _, res := range resp.Data.Result {
+		labels := make(map[string]string, len(res.Metric))
+		for k, v := range res.Metric {
+			if k != "__name__" && k != "alertstate" {
+				labels[k] = v
+			}
+		}
+
+		var stamps []time.Time
+		for _, sample := range res.Values {
+			if len(sample) != 2 {
+				continue
+			}
+			ts, err := strconv.ParseFloat(fmt.Sprint(sample[0]), 64)
+			if err != nil {
+				continue
+			}
+			stamps = append(stamps, time.Unix(0, int64(ts*float64(time.Second))))
+		}
+
+		series = append(series, historySeries{
+			Clusters:  []string{t.Name},
+			Labels:    labels,
+			Intervals: buildIntervals(stamps, step, end),
+		})
+	}
+	return series, nil
+}
+
+// buildIntervals joins consecutive sample timestamps into firing intervals.
+// A gap larger than one and a half steps means the alert resolved in between.
+func buildIntervals(stamps []time.Time, step time.Duration, end time.Time) []firingInterval {
+	var intervals []firingInterval
+	for _, t := range stamps {
+		if n := len(intervals); n > 0 && t.Sub(intervals[n-1].End) <= step+step/2 {
+			intervals[n-1].End = t
+			continue
+		}
+		intervals = append(intervals, firingInterval{Start: t, End: t})
+	}
+
+	for i := range intervals {
+		if end.Sub(intervals[i].End) <= step+step/2 {
+			intervals[i].Ongoing = true
+			intervals[i].End = end
+			continue
+		}
+		// Each sample stands for the step that follows it
+		intervals[i].End = intervals[i].End.Add(step)
+	}
+	return intervals
+}
+
+func (h historySeries) totalFiring() time.Duration {
+	var total time.Duration
+	for _, iv := range h.Intervals {
+		total += iv.End.Sub(iv.Start)
+	}
+	return total
+}
+
+// meanTimeToResolve averages the intervals that have resolved
+func (h historySeries) meanTimeToResolve() (time.Duration, bool) {
+	var total time.Duration
+	n := 0
+	for _, iv := range h.Intervals {
+		if !iv.Ongoing {
+			total += iv.End.Sub(iv.Start)
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, false
+	}
+	return total / time.Duration(n), true
+}
+
+func (h historySeries) ongoing() bool {
+	for _, iv := range h.Intervals {
+		if iv.Ongoing {
+			return true
+		}
+	}
+	return false
+}
+
+// title renders "AlertName{other labels}"
+func (h historySeries) title() string {
+	rest := make(map[string]string, len(h.Labels))
+	for k, v := range h.Labels {
+		if k != "alertname" {
+			rest[k] = v
+		}
+	}
+	return h.Labels["alertname"] + formatMetric(rest)
+}
+
+// aggregateHistory merges series with identical labels from different
+// clusters; each cluster's firings still count separately
+func aggregateHistory(all []historySeries) []historySeries {
+	merged := make(map[string]*historySeries)
+	var order []string
+	for _, s := range all {
+		key := s.title()
+		m, ok := merged[key]
+		if !ok {
+			m = &historySeries{Labels: s.Labels}
+			merged[key] = m
+			order = append(order, key)
+		}
+		m.Intervals = append(m.Intervals, s.Intervals...)
+		m.Clusters = append(m.Clusters, s.Clusters...)
+	}
+
+	result := make([]historySeries, 0, len(order))
+	for _, key := range order {
+		result = append(result, *merged[key])
+	}
+	return result
+}
+
+func printHistory(series []historySeries, start, end time.Time, top int) {
+	if len(series) == 0 {
+		fmt.Println("   ✅ No firing alerts in this window.")
+		return
+	}
+
+	// Noisiest first: most firings, then longest total firing time
+	sort.SliceStable(series, func(i, j int) bool {
+		if len(series[i].Intervals) != len(series[j].Intervals) {
+			return len(series[i].Intervals) > len(series[j].Intervals)
+		}
+		return series[i].totalFiring() > series[j].totalFiring()
+	})
+
+	bucket := end.Sub(start) / timelineWidth
+	for i, s := range series {
+		if top > 0 && i >= top {
+			fmt.Printf("\n   … %d more label sets (raise --top to see them)\n", len(series)-top)
+			break
+		}
+
+		fmt.Printf("\n   📈 %s\n", s.title())
+		if len(s.Clusters) > 1 {
+			fmt.Printf("      clusters: %s\n", strings.Join(s.Clusters, ", "))
+		}
+
+		mttr := "n/a"
+		if d, ok := s.meanTimeToResolve(); ok {
+			mttr = formatDuration(d)
+		}
+		state := ""
+		if s.ongoing() {
+			state = "  🔴 still firing"
+		}
+		fmt.Printf("      firings: %d   firing for: %s   mean time to resolve: %s%s\n",
+			len(s.Intervals), formatDuration(s.totalFiring()), mttr, state)
+		fmt.Printf("      |%s|  %s per char\n", timeline(s.Intervals, start, bucket), formatDuration(bucket))
+	}
+}
+
+// timeline draws one character per bucket: █ when the alert fired in it
+func timeline(intervals []firingInterval, start time.Time, bucket time.Duration) string {
+	var sb strings.Builder
+	for i := 0; i < timelineWidth; i++ {
+		from := start.Add(time.Duration(i) * bucket)
+		to := from.Add(bucket)
+		mark := '·'
+		for _, iv := range intervals {
+			if iv.Start.Before(to) && iv.End.After(from) {
+				mark = '█'
+				break
+			}
+		}
+		sb.WriteRune(mark)
+	}
+	return sb.String()
+}
@@ -0,0 +1,207 @@
+package alert
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Inventory lists the standalone servers checked over SSH
+//
+//	defaults:
+//	  user: ubuntu
+//	hosts:
+//	  - name: web-01
+//	    address: 10.0.0.11
+//	    groups: [linuxaid]
+//	  - name: db-01
+//	    address: 10.0.1.5
+//	    port: 2222
+//	    jump: admin@bastion.example.com
+//	    namespace: monitoring
+//	groups:
+//	  eu: [web-01, db-01]
+type Inventory struct {
+	// Defaults apply to every host that doesn't set the field itself
+	Defaults InventoryHost   `yaml:"defaults"`
+	Hosts    []InventoryHost `yaml:"hosts"`
+	// Groups adds hosts to groups by name, in addition to a host's own groups
+	Groups map[string][]string `yaml:"groups"`
+}
+
+// InventoryHost is one SSH server of the inventory
+type InventoryHost struct {
+	Name    string `yaml:"name"`
+	Address string `yaml:"address"` // defaults to Name
+	User    string `yaml:"user"`
+	Port    int    `yaml:"port"`
+	Jump    string `yaml:"jump"` // ssh -J, e.g. admin@bastion:22
+
+	// Alertmanager overrides for this host
+	Namespace string `yaml:"namespace"`
+	Service   string `yaml:"service"`
+
+	Groups []string `yaml:"groups"`
+}
+
+// inventoryPath returns the default inventory location
+func inventoryPath() string {
+	return filepath.Join(configDir(), "inventory.yaml")
+}
+
+func loadInventory(path string) (*Inventory, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var inv Inventory
+	if err := yaml.Unmarshal(data, &inv); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	if err := inv.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return &inv, nil
+}
+
+func (inv *Inventory) validate() error {
+	names := make(map[string]bool)
+	for i, h := range inv.Hosts {
+		if h.Name == "" {
+			return fmt.Errorf("host #%d has no name", i+1)
+		}
+		if names[h.Name] {
+			return fmt.Errorf("duplicate host %q", h.Name)
+		}
+		names[h.Name] = true
+	}
+	for group, members := range inv.Groups {
+		for _, name := range members {
+			if !names[name] {
+				return fmt.Errorf("group %q: unknown host %q", group, name)
+			}
+		}
+	}
+	return nil
+}
+
+// host returns h with the inventory defaults filled in
+func (inv *Inventory) host(h InventoryHost) InventoryHost {
+	d := inv.Defaults
+	if h.Address == "" {
+		h.Address = h.Name
+	}
+	if h.User == "" {
+		h.User = d.User
+	}
+	if h.Port == 0 {
+		h.Port = d.Port
+	}
+	if h.Jump == "" {
+		h.Jump = d.Jump
+	}
+	if h.Namespace == "" {
+		h.Namespace = d.Namespace
+	}
+	if h.Service == "" {
+		h.Service = d.Service
+	}
+	return h
+}
+
+// groupNames lists every group defined in the inventory
+func (inv *Inventory) groupNames() []string {
+	seen := map[string]bool{"all": true}
+	for _, h := range inv.Hosts {
+		for _, g := range h.Groups {
+			seen[g] = true
+		}
+	}
+	for g := range inv.Groups {
+		seen[g] = true
+	}
+
+	names := make([]string, 0, len(seen))
+	for g := range seen {
+		names = append(names, g)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// HostsIn returns the hosts of a group in inventory order; "all" selects
+// every host
+func (inv *Inventory) HostsIn(group string) ([]InventoryHost, error) {
+	members := make(map[string]bool)
+	for _, name := range inv.Groups[group] {
+		members[name] = true
+	}
+
+	var hosts []InventoryHost
+	for _, h := range inv.Hosts {
+		if group == "all" || members[h.Name] || containsString(h.Groups, group) {
+			hosts = append(hosts, inv.host(h))
+		}
+	}
+	if len(hosts) == 0 && !containsString(inv.groupNames(), group) {
+		return nil, fmt.Errorf("unknown inventory group %q (have: %s)", group, strings.Join(inv.groupNames(), ", "))
+	}
+	return hosts, nil
+}
+
+// Target converts a host into an SSH target
+func (h InventoryHost) Target() Target {
+	server := h.Address
+	if h.User != "" {
+		server = h.User + "@" + h.Address
+	}
+	return Target{
+		Name:      h.Name,
+		Server:    server,
+		SSHPort:   h.Port,
+		Jump:      h.Jump,
+		Namespace: h.Namespace,
+		Service:   h.Service,
+	}
+}
+
+// inventorySource is the ClusterSource for an inventory group
+type inventorySource struct {
+	path  string
+	group string
+}
+
+func (s inventorySource) Targets() ([]Target, error) {
+	inv, err := loadInventory(s.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load inventory: %v", err)
+	}
+	hosts, err := inv.HostsIn(s.group)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make([]Target, 0, len(hosts))
+	for _, h := range hosts {
+		targets = append(targets, h.Target())
+	}
+	return targets, nil
+}
+
+func (s inventorySource) String() string {
+	return fmt.Sprintf("inventory hosts in group '%s'", s.group)
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,257 @@
+package alert
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"god/cmd/config"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// dashboardLead is how far before StartsAt a dashboard link starts, so the
+// graph shows what led up to the alert
+const dashboardLead = 15 * time.Minute
+
+// Annotation keys god reads links from, in order of preference
+var (
+	runbookKeys   = []string{"runbook_url", "runbook"}
+	dashboardKeys = []string{"dashboard", "dashboard_url", "grafana_url"}
+)
+
+func firstAnnotation(a Alert, keys []string) string {
+	for _, k := range keys {
+		if v := strings.TrimSpace(a.Annotations[k]); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// runbookURL returns the alert's runbook link, or "" when it has none
+func runbookURL(a Alert) string {
+	return firstAnnotation(a, runbookKeys)
+}
+
+// dashboardURL returns the alert's dashboard link with a time range around
+// the alert. The annotation may use {{ .From }} and {{ .To }} (Unix ms);
+// otherwise Grafana's from/to parameters are added unless already set.
+func dashboardURL(a Alert, now time.Time) string {
+	raw := firstAnnotation(a, dashboardKeys)
+	if raw == "" {
+		return ""
+	}
+
+	start, err := time.Parse(time.RFC3339, a.StartsAt)
+	if err != nil {
+		return raw
+	}
+	from := strconv.FormatInt(start.Add(-dashboardLead).UnixMilli(), 10)
+	to := strconv.FormatInt(now.UnixMilli(), 10)
+
+	if strings.Contains(raw, "{{") {
+		tmpl, err := template.New("dashboard").Option("missingkey=zero").Parse(raw)
+		if err != nil {
+			return raw
+		}
+		var b bytes.Buffer
+		data := map[string]interface{}{"From": from, "To": to, "Labels": a.Labels}
+		if err := tmpl.Execute(&b, data); err != nil {
+			return raw
+		}
+		return b.String()
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	q := u.Query()
+	if q.Get("from") != "" || q.Get("to") != "" {
+		return raw
+	}
+	q.Set("from", from)
+	q.Set("to", to)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// printAlertDetails renders the annotations and links of one alert below
+// its line in the list
+func printAlertDetails(a Alert, now time.Time) {
+	indent := "         "
+	var meta []string
+	if sev := a.Labels["severity"]; sev != "" {
+		meta = append(meta, sev)
+	}
+	if start, err := time.Parse(time.RFC3339, a.StartsAt); err == nil {
+		meta = append(meta, "since "+start.Local().Format("2006-01-02 15:04"))
+	}
+	meta = append(meta, shortFingerprint(a))
+	fmt.Printf("%s%s\n", indent, strings.Join(meta, " · "))
+
+	if s := strings.TrimSpace(a.Annotations["summary"]); s != "" {
+		fmt.Printf("%s📝 %s\n", indent, s)
+	}
+	if d := strings.TrimSpace(a.Annotations["description"]); d != "" {
+		for _, line := range strings.Split(d, "\n") {
+			fmt.Printf("%s   %s\n", indent, strings.TrimSpace(line))
+		}
+	}
+	if r := runbookURL(a); r != "" {
+		fmt.Printf("%s📖 Runbook:   %s\n", indent, r)
+	} else {
+		fmt.Printf("%s⚠️  No runbook annotation\n", indent)
+	}
+	if d := dashboardURL(a, now); d != "" {
+		fmt.Printf("%s📊 Dashboard: %s\n", indent, d)
+	}
+}
+
+// shortFingerprint is the part of the fingerprint shown in lists; `god alert
+// open` accepts any unique prefix
+func shortFingerprint(a Alert) string {
+	key := a.Key()
+	if len(key) > 8 {
+		return key[:8]
+	}
+	return key
+}
+
+// warnMissingRunbooks prints one line naming the alerts without a runbook
+func warnMissingRunbooks(alerts []Alert) {
+	seen := make(map[string]bool)
+	var names []string
+	for _, a := range alerts {
+		name := a.Labels["alertname"]
+		if runbookURL(a) == "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	if len(names) > 0 {
+		fmt.Printf("⚠️  No runbook annotation: %s\n", strings.Join(names, ", "))
+	}
+}
+
+// --- god alert open ---
+
+// openFlags holds the flags of god alert open
+type openFlags struct {
+	*flag.FlagSet
+	endpoints   *endpointFlags
+	kubeContext *string
+	dashboard   *bool
+	browser     *bool
+}
+
+func newOpenFlags() *openFlags {
+	f := &openFlags{FlagSet: flag.NewFlagSet("open", flag.ExitOnError)}
+	f.endpoints = addEndpointFlags(f.FlagSet, true)
+	f.kubeContext = f.String("context", "", "Kubeconfig context to use (default: current context)")
+	f.dashboard = f.Bool("dashboard", false, "Use the dashboard link instead of the runbook")
+	f.browser = f.Bool("browser", false, "Launch the link in the default browser")
+	return f
+}
+
+func runOpen(args []string) {
+	f := newOpenFlags()
+	ef, kubeContext, dashboard, browser := f.endpoints, f.kubeContext, f.dashboard, f.browser
+
+	// Allow the reference before the flags: god alert open 3 --browser
+	var ref string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		ref, args = args[0], args[1:]
+	}
+	config.Apply(f.FlagSet, "alert")
+	f.Parse(args)
+	configureEndpoints(ef)
+	configureDisplay(true)
+	if ref == "" {
+		ref = f.FlagSet.Arg(0)
+	}
+
+	if ref == "" {
+		fmt.Println("❌ Error: Missing alert, e.g. god alert open 3 (index from `god alert list`) or a fingerprint")
+		os.Exit(1)
+	}
+
+	target := defaultTarget()
+	if *kubeContext != "" {
+		target = Target{Name: *kubeContext, Context: *kubeContext}
+	}
+
+	alerts, err := FetchAlerts(target)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Same order as `god alert list`, so indexes match
+	sortAlerts(alerts)
+	a, err := findAlert(alerts, ref)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	kind, link := "runbook", runbookURL(a)
+	if *dashboard {
+		kind, link = "dashboard", dashboardURL(a, time.Now())
+	}
+	if link == "" {
+		fmt.Printf("⚠️  %s → %s has no %s annotation\n", a.Labels["alertname"], alertTarget(a), kind)
+		os.Exit(1)
+	}
+
+	fmt.Println(link)
+	if *browser {
+		if err := openBrowser(link); err != nil {
+			fmt.Printf("❌ Could not launch a browser: %v\n", err)
+			os.Exit(1)
+		}
+	}
+}
+
+// findAlert resolves a 1-based index from `god alert list` or a fingerprint
+// prefix
+func findAlert(alerts []Alert, ref string) (Alert, error) {
+	if n, err := strconv.Atoi(ref); err == nil && n >= 1 && n <= len(alerts) && len(ref) < 8 {
+		return alerts[n-1], nil
+	}
+
+	var matches []Alert
+	for _, a := range alerts {
+		if strings.HasPrefix(a.Key(), ref) {
+			matches = append(matches, a)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return Alert{}, fmt.Errorf("no active alert with index or fingerprint '%s' (%d alerts)", ref, len(alerts))
+	case 1:
+		return matches[0], nil
+	}
+	return Alert{}, fmt.Errorf("fingerprint prefix '%s' matches %d alerts", ref, len(matches))
+}
+
+// openBrowser launches url with the platform's opener
+func openBrowser(link string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", link)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", link)
+	default:
+		cmd = exec.Command("xdg-open", link)
+	}
+	_, err := audited(Target{}, cmd, "", runOnly)
+	return err
+}
@@ -1,12 +1,17 @@
 package alert
 
 import (
+	"context"
 	"encoding/json"
 	"flag"
 	"fmt"
+	"god/cmd/config"
+	"hash/fnv"
 	"os"
 	"os/exec"
+	"sort"
 	"strings"
+	"time"
 )
 
 // Alert represents the JSON structure returned by Alertmanager
@@ -14,98 +19,166 @@ type Alert struct {
 	Labels      map[string]string `json:"labels"`
 	Annotations map[string]string `json:"annotations"`
 	StartsAt    string            `json:"startsAt"`
+	EndsAt      string            `json:"endsAt,omitempty"`
+	Fingerprint string            `json:"fingerprint,omitempty"`
 }
 
-func runList(args []string) {
-	listCmd := flag.NewFlagSet("list", flag.ExitOnError)
-	port := listCmd.String("port", "9093", "Local port (used for api proxy)")
-	namespace := listCmd.String("n", "monitoring", "Namespace of the Alertmanager service")
-	service := listCmd.String("svc", "svc/alertmanager-operated", "Service name")
-	listCmd.Parse(args)
+// Key returns the Alertmanager fingerprint, or a stable hash of the labels
+// when the source did not provide one
+func (a Alert) Key() string {
+	if a.Fingerprint != "" {
+		return a.Fingerprint
+	}
+	names := make([]string, 0, len(a.Labels))
+	for k := range a.Labels {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	h := fnv.New64a()
+	for _, k := range names {
+		h.Write([]byte(k + "\xff" + a.Labels[k] + "\xff"))
+	}
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// listFlags holds the flags of god alert list
+type listFlags struct {
+	*flag.FlagSet
+	endpoints   *endpointFlags
+	kubeContext *string
+	details     *bool
+	noColor     *bool
+}
+
+func newListFlags() *listFlags {
+	f := &listFlags{FlagSet: flag.NewFlagSet("list", flag.ExitOnError)}
+	f.endpoints = addEndpointFlags(f.FlagSet, true)
+	f.kubeContext = f.String("context", "", "Kubeconfig context to use (default: current context)")
+	f.details = f.Bool("details", false, "Show summary, description, runbook and dashboard links")
+	f.noColor = addDisplayFlags(f.FlagSet)
+	return f
+}
 
-	clusterName := getClusterName()
-	fmt.Printf("🌍 Cluster: %s\n", clusterName)
+func runList(args []string) {
+	f := newListFlags()
+	ef, kubeContext, details, noColor := f.endpoints, f.kubeContext, f.details, f.noColor
+	config.Apply(f.FlagSet, "alert")
+	f.Parse(args)
+	configureEndpoints(ef)
+	configureDisplay(*noColor)
+
+	target := defaultTarget()
+	if *kubeContext != "" {
+		target = Target{Name: *kubeContext, Context: *kubeContext}
+	}
+	fmt.Printf("🌍 Cluster: %s\n", target.Name)
 
-	alerts, err := FetchAlerts("", *namespace, *service, *port)
+	alerts, err := FetchAlerts(target)
 	if err != nil {
 		fmt.Printf("❌ Error: %v\n", err)
 		os.Exit(1)
 	}
 
-	printAlerts(alerts)
+	sortAlerts(alerts)
+	printAlerts(alerts, alertView{numbered: true, details: *details})
+	os.Exit(severityExitCode(alerts))
 }
 
-// FetchAlerts queries Alertmanager via the Kubernetes API server proxy
-func FetchAlerts(server, namespace, service, port string) ([]Alert, error) {
-	// Clean up service name (e.g., "svc/alertmanager-operated" -> "alertmanager-operated")
-	svcName := strings.TrimPrefix(service, "svc/")
+// FetchAlerts queries the target's Alertmanager via the Kubernetes API
+// server proxy, or directly when it is configured by URL
+func FetchAlerts(t Target) ([]Alert, error) {
+	alerts, _, err := fetchAlerts(t)
+	return alerts, err
+}
+
+// fetchAlerts is FetchAlerts that also returns the command it ran, for
+// --report
+func fetchAlerts(t Target) ([]Alert, CommandRecord, error) {
+	am := alertmanagerEndpoint(t)
+	alertsPath := "/api/v2/alerts?active=true&inhibited=false&silenced=false&unprocessed=false"
 
 	// Construct the direct API proxy path to the Alertmanager pod
-	apiPath := fmt.Sprintf("/api/v1/namespaces/%s/services/%s:%s/proxy/api/v2/alerts?active=true&inhibited=false&silenced=false&unprocessed=false", namespace, svcName, port)
+	apiPath := am.ProxyPath(alertsPath)
 
 	var output []byte
 	var err error
+	var cmdStr string
+	start := time.Now()
+
+	if am.Direct() {
+		cmdStr = "GET " + redactedURL(am.URL+alertsPath)
+		output, err = directGet(context.Background(), t, am, alertsPath)
+		if err != nil {
+			return nil, newCommandRecord(cmdStr, start, err), fmt.Errorf("failed to fetch alerts: %v", err)
+		}
+	} else if t.IsSSH() {
+		fmt.Fprintln(os.Stderr, "   [SSH] Fetching alerts... (Touch YubiKey or enter sudo password if prompted)")
 
-	if server != "" {
-		fmt.Println("   [SSH] Fetching alerts... (Touch YubiKey or enter sudo password if prompted)")
-
-		cmdStr := fmt.Sprintf("sudo -i kubectl get --raw '%s'", apiPath)
-		cmd := exec.Command("ssh", "-t", server, cmdStr)
+		cmdStr = fmt.Sprintf("sudo -i kubectl get --raw '%s'", apiPath)
+		cmd := sshCommand(context.Background(), t, cmdStr)
 
 		// Connect Stdin so the TTY can securely receive the YubiKey touch or password
 		cmd.Stdin = os.Stdin
-		output, err = cmd.CombinedOutput()
+		output, err = audited(t, cmd, cmdStr, (*exec.Cmd).CombinedOutput)
 	} else {
-		cmd := exec.Command("kubectl", "get", "--raw", apiPath)
-		output, err = cmd.CombinedOutput()
+		cmd := exec.Command("kubectl", kubectlArgs(t, "get", "--raw", apiPath)...)
+		cmdStr = shellJoin(cmd.Args)
+		output, err = audited(t, cmd, "", (*exec.Cmd).CombinedOutput)
 	}
+	rec := newCommandRecord(cmdStr, start, err)
 
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch alerts: %v\n      Raw Output: %s", err, string(output))
+		return nil, rec, fmt.Errorf("failed to fetch alerts: %v\n      Raw Output: %s", err, string(output))
 	}
 
 	// SSH with a TTY (-t) sometimes injects MOTD before the JSON,
 	// and "Shared connection closed" after the JSON.
 	// We scan the output to find the exact boundaries of the JSON array.
-	outStr := string(output)
-	startIdx := strings.Index(outStr, "[")
-	endIdx := strings.LastIndex(outStr, "]")
-
-	if startIdx == -1 || endIdx == -1 || startIdx > endIdx {
-		return nil, fmt.Errorf("invalid response from alertmanager (expected JSON array): %s", outStr)
+	jsonBytes := extractJSON(output, '[', ']')
+	if jsonBytes == nil {
+		return nil, rec, fmt.Errorf("invalid response from alertmanager (expected JSON array): %s", string(output))
 	}
 
-	// Extract purely the JSON part (from the first '[' to the last ']')
-	jsonBytes := []byte(outStr[startIdx : endIdx+1])
-
 	var alerts []Alert
 	if err := json.Unmarshal(jsonBytes, &alerts); err != nil {
-		return nil, fmt.Errorf("failed to parse alerts JSON: %v\n      Extracted String: %s", err, string(jsonBytes))
+		return nil, rec, fmt.Errorf("failed to parse alerts JSON: %v\n      Extracted String: %s", err, string(jsonBytes))
 	}
 
-	return alerts, nil
+	return alerts, rec, nil
 }
 
-func printAlerts(alerts []Alert) {
+// extractJSON returns the bytes from the first open to the last close
+// delimiter, or nil when the output contains no such span
+func extractJSON(output []byte, open, close byte) []byte {
+	outStr := string(output)
+	startIdx := strings.IndexByte(outStr, open)
+	endIdx := strings.LastIndexByte(outStr, close)
+
+	if startIdx == -1 || endIdx == -1 || startIdx > endIdx {
+		return nil
+	}
+	return []byte(outStr[startIdx : endIdx+1])
+}
+
+// alertView selects how printAlerts renders alerts
+type alertView struct {
+	// numbered prefixes each alert with its index for `god alert open`
+	numbered bool
+	// details adds annotations and links below each alert
+	details bool
+}
+
+func printAlerts(alerts []Alert, view alertView) {
 	if len(alerts) == 0 {
 		fmt.Println("✅ No active alerts.")
 		return
 	}
 
+	now := time.Now()
 	fmt.Printf("🔥 Found %d active alerts:\n", len(alerts))
-	for _, alert := range alerts {
+	for i, alert := range alerts {
 		name := alert.Labels["alertname"]
-		ns := alert.Labels["namespace"]
-		target := "cluster-wide"
-
-		if pod, ok := alert.Labels["pod"]; ok {
-			target = pod
-		} else if instance, ok := alert.Labels["instance"]; ok {
-			target = instance
-		}
-		if ns == "" {
-			ns = "global"
-		}
 
 		// --- NEW: Extract extra context ---
 		// Look for the "name" label (used by linux systemd alerts)
@@ -114,13 +187,43 @@ func printAlerts(alerts []Alert) {
 			extraContext = fmt.Sprintf("  [%s]", val)
 		}
 
-		fmt.Printf("   🔴 %-35s -> %s/%s%s\n", name, ns, target, extraContext)
+		index := ""
+		if view.numbered {
+			index = fmt.Sprintf("%3d. ", i+1)
+		}
+		var meta []string
+		if sev := alert.Labels["severity"]; sev != "" {
+			meta = append(meta, sev)
+		}
+		if firing := firingFor(alert, now); firing != "" {
+			meta = append(meta, firing)
+		}
+		status := ""
+		if len(meta) > 0 {
+			status = "  (" + strings.Join(meta, ", ") + ")"
+		}
+		fmt.Printf("   %s %s%s -> %s%s%s\n", levelIcons[alertLevel(alert)], index,
+			colorize(alert, fmt.Sprintf("%-35s", name)), alertTarget(alert), extraContext, status)
+		if view.details {
+			printAlertDetails(alert, now)
+		}
+	}
+	if view.numbered && !view.details {
+		warnMissingRunbooks(alerts)
 	}
 	fmt.Println("")
 }
 
+// kubectlArgs prefixes args with --context when the target names one
+func kubectlArgs(t Target, args ...string) []string {
+	if t.Context == "" {
+		return args
+	}
+	return append([]string{"--context", t.Context}, args...)
+}
+
 func getClusterName() string {
-	out, err := exec.Command("kubectl", "config", "current-context").Output()
+	out, err := audited(Target{}, exec.Command("kubectl", "config", "current-context"), "", (*exec.Cmd).Output)
 	if err != nil {
 		return "Unknown"
 	}
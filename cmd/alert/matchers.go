@@ -0,0 +1,98 @@
+package alert
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Matcher is a single Prometheus-style label matcher, e.g. severity="critical"
+// or instance=~"db-.*"
+type Matcher struct {
+	Name  string
+	Op    string // one of =, !=, =~, !~
+	Value string
+	re    *regexp.Regexp
+}
+
+var matcherNameRe = regexp.MustCompile(`^\s*([a-zA-Z_][a-zA-Z0-9_]*)\s*(=~|!~|!=|=)\s*(.*?)\s*$`)
+
+// ParseMatcher parses `name<op>value`, where the value may be double quoted
+func ParseMatcher(s string) (*Matcher, error) {
+	parts := matcherNameRe.FindStringSubmatch(s)
+	if parts == nil {
+		return nil, fmt.Errorf("invalid matcher %q (expected name=value, name!=value, name=~regex or name!~regex)", s)
+	}
+
+	value := parts[3]
+	if strings.HasPrefix(value, `"`) {
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid quoted value in matcher %q: %v", s, err)
+		}
+		value = unquoted
+	}
+
+	m, err := newMatcher(parts[1], parts[2], value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regex in matcher %q: %v", s, err)
+	}
+	return m, nil
+}
+
+// newMatcher builds a matcher, compiling regex values anchored as in Prometheus
+func newMatcher(name, op, value string) (*Matcher, error) {
+	m := &Matcher{Name: name, Op: op, Value: value}
+	if op == "=~" || op == "!~" {
+		re, err := regexp.Compile("^(?:" + value + ")$")
+		if err != nil {
+			return nil, err
+		}
+		m.re = re
+	}
+	return m, nil
+}
+
+// ParseMatchers parses a list of matchers, failing on the first invalid one
+func ParseMatchers(list []string) ([]*Matcher, error) {
+	var ms []*Matcher
+	for _, s := range list {
+		m, err := ParseMatcher(s)
+		if err != nil {
+			return nil, err
+		}
+		ms = append(ms, m)
+	}
+	return ms, nil
+}
+
+// Matches reports whether the label value satisfies the matcher. A missing
+// label is treated as the empty string, as in Prometheus.
+func (m *Matcher) Matches(value string) bool {
+	switch m.Op {
+	case "=":
+		return value == m.Value
+	case "!=":
+		return value != m.Value
+	case "=~":
+		return m.re.MatchString(value)
+	case "!~":
+		return !m.re.MatchString(value)
+	}
+	return false
+}
+
+func (m *Matcher) String() string {
+	return fmt.Sprintf("%s%s%q", m.Name, m.Op, m.Value)
+}
+
+// matchesAll reports whether every matcher is satisfied by the label set
+func matchesAll(ms []*Matcher, labels map[string]string) bool {
+	for _, m := range ms {
+		if !m.Matches(labels[m.Name]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,242 @@
+package alert
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// NotifyConfig describes one notification sink. In config.yaml sinks are
+// named and picked with --notify <name>; --notify <type>=<url> defines an
+// ad-hoc one.
+type NotifyConfig struct {
+	Name string `yaml:"name"`
+	Type string `yaml:"type"` // webhook, slack or smtp
+	// URL is the webhook endpoint, or smtp://[user[:password]@]host:port
+	URL string `yaml:"url"`
+
+	// SMTP only
+	From        string   `yaml:"from"`
+	To          []string `yaml:"to"`
+	PasswordEnv string   `yaml:"password_env"`
+}
+
+// Notifier delivers a Digest to one sink
+type Notifier interface {
+	Send(d *Digest) error
+	String() string
+}
+
+// Digest is the summary of a scan that is sent to every sink. It is also
+// the JSON body posted by the generic webhook sink.
+type Digest struct {
+	Time     time.Time       `json:"time"`
+	Source   string          `json:"source"`
+	Total    int             `json:"total"`
+	Clusters []DigestCluster `json:"clusters"`
+}
+
+// DigestCluster is the part of a Digest about a single cluster
+type DigestCluster struct {
+	Name   string  `json:"name"`
+	Error  string  `json:"error,omitempty"`
+	Alerts []Alert `json:"alerts"`
+}
+
+func newDigest(source string, results []ClusterAlerts) *Digest {
+	d := &Digest{Time: time.Now(), Source: source}
+	for _, res := range results {
+		d.Clusters = append(d.Clusters, DigestCluster{Name: res.Cluster, Error: res.Err, Alerts: res.Alerts})
+		d.Total += len(res.Alerts)
+	}
+	return d
+}
+
+// Title is a one-line summary used as subject and header
+func (d *Digest) Title() string {
+	if d.Total == 0 {
+		return fmt.Sprintf("✅ No active alerts across %d clusters (%s)", len(d.Clusters), d.Source)
+	}
+	return fmt.Sprintf("🔥 %d active alerts across %d clusters (%s)", d.Total, len(d.Clusters), d.Source)
+}
+
+// Summary returns "AlertA ×3, AlertB" style counts for one cluster
+func (c DigestCluster) Summary() string {
+	if c.Error != "" {
+		return "⚠️ scan failed: " + c.Error
+	}
+	if len(c.Alerts) == 0 {
+		return "no active alerts"
+	}
+
+	counts := make(map[string]int)
+	for _, a := range c.Alerts {
+		counts[a.Labels["alertname"]]++
+	}
+	names := make([]string, 0, len(counts))
+	for name := range counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if counts[names[i]] != counts[names[j]] {
+			return counts[names[i]] > counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		if counts[name] > 1 {
+			parts = append(parts, fmt.Sprintf("%s ×%d", name, counts[name]))
+		} else {
+			parts = append(parts, name)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// hash identifies the alert set so unchanged scans don't notify again
+func (d *Digest) hash() string {
+	var keys []string
+	for _, c := range d.Clusters {
+		if c.Error != "" {
+			keys = append(keys, c.Name+"|error")
+			continue
+		}
+		for _, a := range c.Alerts {
+			keys = append(keys, c.Name+"|"+a.Key())
+		}
+	}
+	sort.Strings(keys)
+
+	sum := sha256.Sum256([]byte(strings.Join(keys, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// parseNotifyFlag resolves --notify against the configured sinks, or
+// parses the ad-hoc <type>=<url> form
+func parseNotifyFlag(value string, configured []NotifyConfig) (NotifyConfig, error) {
+	for _, c := range configured {
+		if c.Name == value {
+			return c, nil
+		}
+	}
+
+	kind, target, ok := strings.Cut(value, "=")
+	if !ok {
+		return NotifyConfig{}, fmt.Errorf("unknown sink %q (not in config and not <type>=<url>)", value)
+	}
+	c := NotifyConfig{Name: kind, Type: kind, URL: target}
+
+	// SMTP recipients travel in the query: smtp://host:587?from=a@x&to=b@x,c@x
+	if kind == "smtp" {
+		u, err := url.Parse(target)
+		if err != nil {
+			return NotifyConfig{}, fmt.Errorf("invalid smtp URL: %v", err)
+		}
+		q := u.Query()
+		c.From = q.Get("from")
+		if to := q.Get("to"); to != "" {
+			c.To = strings.Split(to, ",")
+		}
+		u.RawQuery = ""
+		c.URL = u.String()
+	}
+	return c, nil
+}
+
+func newNotifier(c NotifyConfig) (Notifier, error) {
+	if c.URL == "" {
+		return nil, fmt.Errorf("sink %q has no url", c.Name)
+	}
+	switch c.Type {
+	case "webhook":
+		return &webhookNotifier{url: c.URL}, nil
+	case "slack", "mattermost":
+		return &slackNotifier{url: c.URL}, nil
+	case "smtp":
+		return newSMTPNotifier(c)
+	}
+	return nil, fmt.Errorf("sink %q: unknown type %q (expected webhook, slack or smtp)", c.Name, c.Type)
+}
+
+// --- Change Detection ---
+
+// notifyStatePath stores the last notified digest hash per source and sink
+func notifyStatePath() string {
+	dir := stateDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "notify.json")
+}
+
+func loadNotifyState() map[string]string {
+	state := make(map[string]string)
+	if data, err := os.ReadFile(notifyStatePath()); err == nil {
+		json.Unmarshal(data, &state)
+	}
+	return state
+}
+
+func saveNotifyState(state map[string]string) error {
+	path := notifyStatePath()
+	if path == "" {
+		return fmt.Errorf("cannot determine state directory")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	data, _ := json.MarshalIndent(state, "", "  ")
+	return os.WriteFile(path, data, 0o600)
+}
+
+// sendNotifications delivers the digest to every sink that hasn't been sent
+// this alert set yet. Each sink is tracked on its own, so a failed sink is
+// retried on the next scan and a new one gets the current alert set.
+func sendNotifications(sinks []NotifyConfig, d *Digest, always bool) {
+	state := loadNotifyState()
+	hash := d.hash()
+
+	changed := false
+	for _, c := range sinks {
+		n, err := newNotifier(c)
+		if err != nil {
+			fmt.Printf("⚠️  %v\n", err)
+			continue
+		}
+		key := notifyStateKey(d.Source, c)
+		if !always && state[key] == hash {
+			fmt.Printf("🔕 Alert set unchanged since the last notification via %s, not sending\n", n)
+			continue
+		}
+		if err := n.Send(d); err != nil {
+			fmt.Printf("⚠️  Notification via %s failed: %v\n", n, err)
+			continue
+		}
+		fmt.Printf("📣 Sent digest via %s\n", n)
+		// Only remember the alert set once this sink actually delivered it
+		state[key] = hash
+		changed = true
+	}
+
+	if changed {
+		if err := saveNotifyState(state); err != nil {
+			fmt.Printf("⚠️  Could not save notification state: %v\n", err)
+		}
+	}
+}
+
+// notifyStateKey identifies a sink of a source in the state file. The URL
+// is hashed so webhook tokens and SMTP passwords aren't written to disk.
+func notifyStateKey(source string, c NotifyConfig) string {
+	sum := sha256.Sum256([]byte(c.Type + "|" + c.URL + "|" + c.From + "|" + strings.Join(c.To, ",")))
+	return source + "|" + c.Name + "|" + hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,200 @@
+package alert
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// notifyTimeout bounds a single delivery attempt
+const notifyTimeout = 15 * time.Second
+
+// maxSlackBlocks is Slack's limit of blocks per message
+const maxSlackBlocks = 50
+
+var notifyClient = &http.Client{Timeout: notifyTimeout}
+
+// postJSON sends payload to url and fails on any non-2xx status
+func postJSON(client *http.Client, url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// --- Generic JSON Webhook ---
+
+type webhookNotifier struct {
+	url string
+}
+
+func (n *webhookNotifier) Send(d *Digest) error {
+	return postJSON(notifyClient, n.url, d)
+}
+
+func (n *webhookNotifier) String() string { return "webhook " + redactURL(n.url) }
+
+// --- Slack / Mattermost Incoming Webhook ---
+
+type slackNotifier struct {
+	url string
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type slackBlock struct {
+	Type string     `json:"type"`
+	Text *slackText `json:"text,omitempty"`
+}
+
+// Send posts blocks for Slack plus a plain markdown "text", which is what
+// Mattermost (and Slack notifications) display
+func (n *slackNotifier) Send(d *Digest) error {
+	// Slack rejects header blocks longer than 150 characters
+	blocks := []slackBlock{{Type: "header", Text: &slackText{Type: "plain_text", Text: Truncate(d.Title(), 150)}}}
+	lines := []string{"**" + d.Title() + "**"}
+
+	for _, c := range d.Clusters {
+		lines = append(lines, fmt.Sprintf("- **%s** (%d): %s", c.Name, len(c.Alerts), c.Summary()))
+		line := fmt.Sprintf("*%s* (%d): %s", c.Name, len(c.Alerts), c.Summary())
+
+		if len(blocks) == maxSlackBlocks-1 {
+			blocks = append(blocks, slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: "… more clusters omitted"}})
+			continue
+		}
+		if len(blocks) < maxSlackBlocks {
+			blocks = append(blocks, slackBlock{Type: "section", Text: &slackText{Type: "mrkdwn", Text: Truncate(line, 2900)}})
+		}
+	}
+
+	return postJSON(notifyClient, n.url, map[string]interface{}{
+		"text":   strings.Join(lines, "\n"),
+		"blocks": blocks,
+	})
+}
+
+func (n *slackNotifier) String() string { return "slack " + redactURL(n.url) }
+
+// --- SMTP Email ---
+
+type smtpNotifier struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+	to       []string
+}
+
+func newSMTPNotifier(c NotifyConfig) (*smtpNotifier, error) {
+	u, err := url.Parse(c.URL)
+	if err != nil || u.Scheme != "smtp" || u.Host == "" {
+		return nil, fmt.Errorf("sink %q: url must look like smtp://[user@]host:port", c.Name)
+	}
+	if c.From == "" || len(c.To) == 0 {
+		return nil, fmt.Errorf("sink %q: smtp needs from and to", c.Name)
+	}
+
+	n := &smtpNotifier{addr: u.Host, host: u.Hostname(), from: c.From, to: c.To}
+	if _, _, err := net.SplitHostPort(u.Host); err != nil {
+		n.addr = net.JoinHostPort(u.Host, "25")
+	}
+	if u.User != nil {
+		n.username = u.User.Username()
+		n.password, _ = u.User.Password()
+	}
+	if c.PasswordEnv != "" {
+		n.password = os.Getenv(c.PasswordEnv)
+	}
+	return n, nil
+}
+
+var emailTemplate = template.Must(template.New("email").Parse(`<!DOCTYPE html>
+<html><body style="font-family: sans-serif">
+<h2>{{ .Title }}</h2>
+<p>Scanned at {{ .Time.Format "2006-01-02 15:04:05 MST" }}</p>
+{{ range .Clusters }}
+<h3>{{ .Name }}</h3>
+{{ if .Error }}<p style="color: #b00">Scan failed: {{ .Error }}</p>
+{{ else if not .Alerts }}<p>No active alerts.</p>
+{{ else }}<table border="1" cellpadding="4" cellspacing="0" style="border-collapse: collapse">
+<tr><th>Alert</th><th>Severity</th><th>Namespace</th><th>Target</th><th>Since</th></tr>
+{{ range .Alerts }}<tr><td>{{ index .Labels "alertname" }}</td><td>{{ index .Labels "severity" }}</td><td>{{ index .Labels "namespace" }}</td><td>{{ or (index .Labels "pod") (index .Labels "instance") }}</td><td>{{ .StartsAt }}</td></tr>
+{{ end }}</table>
+{{ end }}{{ end }}
+</body></html>
+`))
+
+func (n *smtpNotifier) Send(d *Digest) error {
+	var body bytes.Buffer
+	if err := emailTemplate.Execute(&body, d); err != nil {
+		return err
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", n.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(n.to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mimeHeader(d.Title()))
+	fmt.Fprintf(&msg, "Date: %s\r\n", d.Time.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/html; charset=UTF-8\r\n\r\n")
+	msg.Write(body.Bytes())
+
+	var auth smtp.Auth
+	if n.username != "" {
+		auth = smtp.PlainAuth("", n.username, n.password, n.host)
+	}
+	return smtp.SendMail(n.addr, auth, n.from, n.to, msg.Bytes())
+}
+
+func (n *smtpNotifier) String() string { return "smtp " + n.addr }
+
+// --- Helpers ---
+
+// mimeHeader encodes non-ASCII header values (emoji in the subject)
+func mimeHeader(s string) string {
+	return mime.BEncoding.Encode("UTF-8", s)
+}
+
+// redactURL hides credentials and webhook tokens from log output
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "(invalid url)"
+	}
+	return u.Scheme + "://" + u.Host + "/…"
+}
+
+// Truncate shortens s to at most max runes, the last one an ellipsis
+func Truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-1]) + "…"
+}
@@ -0,0 +1,300 @@
+package alert
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func testDigest(clusters ...ClusterAlerts) *Digest {
+	if len(clusters) == 0 {
+		clusters = []ClusterAlerts{
+			{Cluster: "prod-eu", Alerts: []Alert{
+				{Labels: map[string]string{"alertname": "KubePodCrashLooping", "severity": "warning", "pod": "web-1"}},
+				{Labels: map[string]string{"alertname": "KubePodCrashLooping", "severity": "warning", "pod": "web-2"}},
+			}},
+			{Cluster: "prod-us", Err: "login failed"},
+		}
+	}
+	return newDigest("--filter prod", clusters)
+}
+
+// recorder is an httptest handler that keeps the bodies posted to it
+type recorder struct {
+	mu     sync.Mutex
+	status int
+	bodies map[string][]string
+}
+
+func newRecorder(t *testing.T) (*recorder, *httptest.Server) {
+	r := &recorder{status: http.StatusOK, bodies: map[string][]string{}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.bodies[req.URL.Path] = append(r.bodies[req.URL.Path], string(body))
+		if strings.HasPrefix(req.URL.Path, "/fail") {
+			http.Error(w, "boom", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(r.status)
+	}))
+	t.Cleanup(srv.Close)
+	return r, srv
+}
+
+func (r *recorder) count(path string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.bodies[path])
+}
+
+func TestWebhookNotifierPostsDigest(t *testing.T) {
+	rec, srv := newRecorder(t)
+	n, err := newNotifier(NotifyConfig{Name: "hook", Type: "webhook", URL: srv.URL + "/hook"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Send(testDigest()); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	var got Digest
+	if err := json.Unmarshal([]byte(rec.bodies["/hook"][0]), &got); err != nil {
+		t.Fatalf("body is not a digest: %v", err)
+	}
+	if got.Source != "--filter prod" || got.Total != 2 || len(got.Clusters) != 2 {
+		t.Errorf("digest = %+v", got)
+	}
+	if got.Clusters[1].Error != "login failed" {
+		t.Errorf("cluster error = %q, want login failed", got.Clusters[1].Error)
+	}
+}
+
+func TestWebhookNotifierFailsOnErrorStatus(t *testing.T) {
+	_, srv := newRecorder(t)
+	n, _ := newNotifier(NotifyConfig{Name: "hook", Type: "webhook", URL: srv.URL + "/fail"})
+	err := n.Send(testDigest())
+	if err == nil || !strings.Contains(err.Error(), "500") || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("Send error = %v, want the 500 status and body", err)
+	}
+}
+
+func TestSlackNotifierMessage(t *testing.T) {
+	rec, srv := newRecorder(t)
+	n, _ := newNotifier(NotifyConfig{Name: "chat", Type: "slack", URL: srv.URL + "/slack"})
+	if err := n.Send(testDigest()); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	var msg struct {
+		Text   string       `json:"text"`
+		Blocks []slackBlock `json:"blocks"`
+	}
+	if err := json.Unmarshal([]byte(rec.bodies["/slack"][0]), &msg); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"2 active alerts across 2 clusters", "**prod-eu** (2): KubePodCrashLooping ×2", "scan failed: login failed"} {
+		if !strings.Contains(msg.Text, want) {
+			t.Errorf("text %q lacks %q", msg.Text, want)
+		}
+	}
+	if len(msg.Blocks) != 3 || msg.Blocks[0].Type != "header" {
+		t.Errorf("blocks = %+v, want a header and a section per cluster", msg.Blocks)
+	}
+}
+
+func TestSlackNotifierLimitsBlocks(t *testing.T) {
+	rec, srv := newRecorder(t)
+	var clusters []ClusterAlerts
+	for i := 0; i < 80; i++ {
+		clusters = append(clusters, ClusterAlerts{Cluster: fmt.Sprintf("c%d", i)})
+	}
+	n, _ := newNotifier(NotifyConfig{Name: "chat", Type: "slack", URL: srv.URL + "/slack"})
+	if err := n.Send(testDigest(clusters...)); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	var msg struct {
+		Blocks []slackBlock `json:"blocks"`
+	}
+	json.Unmarshal([]byte(rec.bodies["/slack"][0]), &msg)
+	if len(msg.Blocks) != maxSlackBlocks {
+		t.Fatalf("%d blocks, want %d", len(msg.Blocks), maxSlackBlocks)
+	}
+	if last := msg.Blocks[len(msg.Blocks)-1]; !strings.Contains(last.Text.Text, "omitted") {
+		t.Errorf("last block = %q, want the omitted note", last.Text.Text)
+	}
+}
+
+func TestSlackNotifierTruncatesHeader(t *testing.T) {
+	rec, srv := newRecorder(t)
+	d := testDigest()
+	d.Source = "--contexts " + strings.Repeat("prod-cluster-", 20)
+	n, _ := newNotifier(NotifyConfig{Name: "chat", Type: "slack", URL: srv.URL + "/slack"})
+	if err := n.Send(d); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	var msg struct {
+		Blocks []slackBlock `json:"blocks"`
+	}
+	json.Unmarshal([]byte(rec.bodies["/slack"][0]), &msg)
+	if header := msg.Blocks[0].Text.Text; len([]rune(header)) > 150 {
+		t.Errorf("header has %d characters, Slack allows 150", len([]rune(header)))
+	}
+}
+
+// fakeSMTP accepts one connection at a time and keeps each message
+type fakeSMTP struct {
+	addr     string
+	mu       sync.Mutex
+	messages []smtpMessage
+}
+
+type smtpMessage struct {
+	from string
+	to   []string
+	data string
+}
+
+func newFakeSMTP(t *testing.T) *fakeSMTP {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	s := &fakeSMTP{addr: ln.Addr().String()}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeSMTP) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { fmt.Fprintf(conn, "%s\r\n", line) }
+
+	var msg smtpMessage
+	reply("220 fake ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimRight(line, "\r\n")
+		switch verb := strings.ToUpper(strings.SplitN(cmd, " ", 2)[0]); verb {
+		case "EHLO", "HELO":
+			reply("250 fake")
+		case "MAIL":
+			msg.from = strings.Trim(strings.TrimPrefix(cmd, "MAIL FROM:"), "<>")
+			reply("250 OK")
+		case "RCPT":
+			msg.to = append(msg.to, strings.Trim(strings.TrimPrefix(cmd, "RCPT TO:"), "<>"))
+			reply("250 OK")
+		case "DATA":
+			reply("354 go ahead")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil || l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			msg.data = data.String()
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			msg = smtpMessage{}
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func TestSMTPNotifierSendsHTMLMail(t *testing.T) {
+	srv := newFakeSMTP(t)
+	c, err := parseNotifyFlag("smtp=smtp://"+srv.addr+"?from=god@example.com&to=a@example.com,b@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := newNotifier(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Send(testDigest()); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if len(srv.messages) != 1 {
+		t.Fatalf("%d messages, want 1", len(srv.messages))
+	}
+	m := srv.messages[0]
+	if m.from != "god@example.com" || strings.Join(m.to, ",") != "a@example.com,b@example.com" {
+		t.Errorf("envelope from %q to %v", m.from, m.to)
+	}
+	for _, want := range []string{"To: a@example.com, b@example.com", "Subject: =?UTF-8?b?", "Content-Type: text/html", "<h3>prod-eu</h3>", "Scan failed: login failed"} {
+		if !strings.Contains(m.data, want) {
+			t.Errorf("message lacks %q:\n%s", want, m.data)
+		}
+	}
+}
+
+func TestSMTPNotifierNeedsRecipients(t *testing.T) {
+	if _, err := newNotifier(NotifyConfig{Name: "mail", Type: "smtp", URL: "smtp://localhost:25", From: "god@example.com"}); err == nil {
+		t.Error("expected an error for a sink without recipients")
+	}
+}
+
+func TestSendNotificationsTracksEachSink(t *testing.T) {
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	rec, srv := newRecorder(t)
+	good := NotifyConfig{Name: "good", Type: "webhook", URL: srv.URL + "/good"}
+	failing := NotifyConfig{Name: "failing", Type: "webhook", URL: srv.URL + "/fail"}
+	d := testDigest()
+
+	sendNotifications([]NotifyConfig{good, failing}, d, false)
+	sendNotifications([]NotifyConfig{good, failing}, d, false)
+	if got := rec.count("/good"); got != 1 {
+		t.Errorf("good sink got %d digests, want 1 (unchanged alert set)", got)
+	}
+	if got := rec.count("/fail"); got != 2 {
+		t.Errorf("failing sink was tried %d times, want 2 (retried)", got)
+	}
+
+	// A sink added later gets the current alert set once
+	added := NotifyConfig{Name: "added", Type: "webhook", URL: srv.URL + "/added"}
+	sendNotifications([]NotifyConfig{good, added}, d, false)
+	sendNotifications([]NotifyConfig{good, added}, d, false)
+	if got := rec.count("/added"); got != 1 {
+		t.Errorf("added sink got %d digests, want 1", got)
+	}
+
+	// A changed alert set goes to every sink again, as does --notify-always
+	sendNotifications([]NotifyConfig{good}, testDigest(ClusterAlerts{Cluster: "prod-eu"}), false)
+	sendNotifications([]NotifyConfig{good}, testDigest(ClusterAlerts{Cluster: "prod-eu"}), true)
+	if got := rec.count("/good"); got != 3 {
+		t.Errorf("good sink got %d digests, want 3", got)
+	}
+}
@@ -0,0 +1,29 @@
+package alert
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// configDir returns $XDG_CONFIG_HOME/god (default ~/.config/god)
+func configDir() string {
+	return xdgDir("XDG_CONFIG_HOME", ".config")
+}
+
+// stateDir returns $XDG_STATE_HOME/god (default ~/.local/state/god), where
+// data god writes for itself between runs is kept
+func stateDir() string {
+	return xdgDir("XDG_STATE_HOME", filepath.Join(".local", "state"))
+}
+
+func xdgDir(env, fallback string) string {
+	base := os.Getenv(env)
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		base = filepath.Join(home, fallback)
+	}
+	return filepath.Join(base, "god")
+}
@@ -0,0 +1,178 @@
+package alert
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// promRuleGroup is a rule group of a Prometheus rule file or of the spec of
+// a PrometheusRule resource
+type promRuleGroup struct {
+	Name  string     `yaml:"name"`
+	Rules []promRule `yaml:"rules"`
+
+	line int
+}
+
+// promRule is an alerting rule (Alert set) or a recording rule (Record set)
+type promRule struct {
+	Alert       string            `yaml:"alert"`
+	Record      string            `yaml:"record"`
+	Labels      map[string]string `yaml:"labels"`
+	Annotations map[string]string `yaml:"annotations"`
+
+	line int
+}
+
+// Name returns the alert or record name
+func (r *promRule) Name() string {
+	if r.Alert != "" {
+		return r.Alert
+	}
+	return r.Record
+}
+
+// promRuleSource is one set of rule groups as promtool reads it. Plain rule
+// files are passed to promtool as they are; the spec.groups of a
+// PrometheusRule resource are first written to a file of their own.
+type promRuleSource struct {
+	file   string // the file the groups were read from
+	path   string // the file promtool reads
+	groups []promRuleGroup
+}
+
+// loadPromRuleFile reads every rule group of a file. Documents of kind
+// PrometheusRule contribute spec.groups, plain rule files their top-level
+// groups; other documents (Deployments, kustomizations, ...) are ignored.
+// Groups that promtool can't read in place are written below tmpDir.
+func loadPromRuleFile(path, tmpDir string) ([]promRuleSource, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []*yaml.Node
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc yaml.Node
+		if err := dec.Decode(&doc); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		if len(doc.Content) > 0 {
+			docs = append(docs, doc.Content[0])
+		}
+	}
+
+	var sources []promRuleSource
+	for _, root := range docs {
+		groupsNode := yamlValue(root, "groups")
+		kind := yamlValue(root, "kind")
+		if kind != nil {
+			if kind.Value != "PrometheusRule" {
+				continue
+			}
+			groupsNode = yamlValue(yamlValue(root, "spec"), "groups")
+		}
+		if groupsNode == nil {
+			continue
+		}
+		if groupsNode.Kind != yaml.SequenceNode {
+			return nil, fmt.Errorf("line %d: groups must be a list", groupsNode.Line)
+		}
+
+		src := promRuleSource{file: path, path: path}
+		for _, gn := range groupsNode.Content {
+			var g promRuleGroup
+			if err := gn.Decode(&g); err != nil {
+				return nil, err
+			}
+			g.line = gn.Line
+			if rulesNode := yamlValue(gn, "rules"); rulesNode != nil && rulesNode.Kind == yaml.SequenceNode {
+				for i, rn := range rulesNode.Content {
+					if i < len(g.Rules) {
+						g.Rules[i].line = rn.Line
+					}
+				}
+			}
+			src.groups = append(src.groups, g)
+		}
+
+		if kind != nil || len(docs) > 1 {
+			if src.path, err = writePlainRuleFile(tmpDir, path, groupsNode); err != nil {
+				return nil, err
+			}
+		}
+		sources = append(sources, src)
+	}
+	return sources, nil
+}
+
+// writePlainRuleFile writes groups as a plain rule file below tmpDir
+func writePlainRuleFile(tmpDir, path string, groups *yaml.Node) (string, error) {
+	doc := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+		{Kind: yaml.ScalarNode, Value: "groups"}, groups,
+	}}
+	data, err := yaml.Marshal(doc)
+	if err != nil {
+		return "", err
+	}
+	f, err := os.CreateTemp(tmpDir, "*-"+filepath.Base(path))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// yamlValue returns the value of key in a mapping node, or nil
+func yamlValue(n *yaml.Node, key string) *yaml.Node {
+	if n == nil || n.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value == key {
+			return n.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// runPromtool runs promtool, which checks and evaluates the rules exactly as
+// the Prometheus release it ships with. A failed check is returned as an
+// *exec.ExitError along with the output.
+func runPromtool(args ...string) (string, error) {
+	if _, err := exec.LookPath("promtool"); err != nil {
+		return "", fmt.Errorf("'promtool' is not installed, get it with Prometheus from https://prometheus.io/download/")
+	}
+	output, err := audited(Target{}, exec.Command("promtool", args...), "", (*exec.Cmd).CombinedOutput)
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return "", err
+	}
+	return string(output), err
+}
+
+// unextractPaths replaces the extracted rule files in promtool output with
+// the files they were read from
+func unextractPaths(output string, sources []promRuleSource) string {
+	var pairs []string
+	for _, src := range sources {
+		if src.path != src.file {
+			pairs = append(pairs, src.path, src.file)
+		}
+	}
+	return strings.NewReplacer(pairs...).Replace(output)
+}
@@ -0,0 +1,84 @@
+package alert
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testPrometheusRule = `apiVersion: apps/v1
+kind: Deployment
+metadata: {name: api}
+---
+apiVersion: monitoring.coreos.com/v1
+kind: PrometheusRule
+metadata: {name: node}
+spec:
+  groups:
+    - name: node
+      rules:
+        - alert: InstanceDown
+          expr: up == 0
+          labels: {severity: critical}
+        - alert: Broken
+          expr: sum by (job) x
+`
+
+func TestLoadPromRuleFile(t *testing.T) {
+	dir := t.TempDir()
+	plain := filepath.Join(dir, "plain.yaml")
+	crd := filepath.Join(dir, "crd.yaml")
+	if err := os.WriteFile(plain, []byte("groups:\n  - name: g\n    rules:\n      - record: a:b\n        expr: sum(b)\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(crd, []byte(testPrometheusRule), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tmpDir := t.TempDir()
+
+	sources, err := loadPromRuleFile(plain, tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sources) != 1 || sources[0].path != plain {
+		t.Errorf("plain rule file: got %+v, want it passed to promtool as is", sources)
+	}
+
+	sources, err = loadPromRuleFile(crd, tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sources) != 1 {
+		t.Fatalf("PrometheusRule: got %d sources, want 1", len(sources))
+	}
+	src := sources[0]
+	if !strings.HasPrefix(src.path, tmpDir) || src.file != crd {
+		t.Errorf("PrometheusRule: promtool reads %s for %s, want an extracted file", src.path, src.file)
+	}
+	data, err := os.ReadFile(src.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(data), "groups:\n") || !strings.Contains(string(data), "sum by (job) x") {
+		t.Errorf("extracted rule file:\n%s", data)
+	}
+
+	// promtool names the rule of a bad expression; it is reported at the
+	// rule's line in the resource
+	l := &ruleLinter{sources: sources}
+	l.promtoolProblem(src, `group "node", rule 2, "Broken": could not parse expression: 1:14: parse error`)
+	l.promtoolProblem(src, `7:3: groupname: "node" is repeated in the same file`)
+	want := []lintProblem{
+		{file: crd, line: 15, rule: "Broken", msg: "could not parse expression: 1:14: parse error"},
+		{file: crd, msg: `7:3: groupname: "node" is repeated in the same file`},
+	}
+	if len(l.problems) != len(want) {
+		t.Fatalf("got %+v, want %+v", l.problems, want)
+	}
+	for i := range want {
+		if l.problems[i] != want[i] {
+			t.Errorf("got %+v, want %+v", l.problems[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"god/cmd/config"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// sparklineWidth is the maximum number of characters per range series
+const sparklineWidth = 60
+
+var sparkChars = []rune("▁▂▃▄▅▆▇█")
+
+// queryResult is the --json output for one target
+type queryResult struct {
+	Target     string     `json:"target"`
+	ResultType string     `json:"resultType,omitempty"`
+	Result     PromResult `json:"result"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// queryFlags holds the flags of god alert query
+type queryFlags struct {
+	*flag.FlagSet
+	targets   *targetFlags
+	endpoints *endpointFlags
+	rangeDur  *time.Duration
+	step      *time.Duration
+	asJSON    *bool
+}
+
+func newQueryFlags() *queryFlags {
+	f := &queryFlags{FlagSet: flag.NewFlagSet("query", flag.ExitOnError)}
+	f.targets = addTargetFlags(f.FlagSet)
+	f.endpoints = addEndpointFlags(f.FlagSet, false)
+	f.rangeDur = f.Duration("range", 0, "Run a range query over this window (e.g. 1h) instead of an instant query")
+	f.step = f.Duration("step", time.Minute, "Resolution of a range query")
+	f.asJSON = f.Bool("json", false, "Print the raw result as JSON")
+	return f
+}
+
+func runQuery(args []string) {
+	f := newQueryFlags()
+	tf, ef, rangeDur, step, asJSON := f.targets, f.endpoints, f.rangeDur, f.step, f.asJSON
+
+	// Allow the query before the flags: god alert query 'up' --range 1h
+	var promQuery string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		promQuery, args = args[0], args[1:]
+	}
+	config.Apply(f.FlagSet, "alert")
+	f.Parse(args)
+	configureEndpoints(ef)
+	if promQuery == "" {
+		promQuery = strings.Join(f.FlagSet.Args(), " ")
+	}
+
+	// With --json, stdout holds nothing but the JSON document
+	msg := os.Stdout
+	if *asJSON {
+		msg = os.Stderr
+	}
+	if promQuery == "" {
+		fmt.Fprintln(msg, "❌ Error: Missing PromQL query, e.g. god alert query 'up == 0'")
+		os.Exit(1)
+	}
+	if *rangeDur < 0 || (*rangeDur > 0 && *step <= 0) {
+		fmt.Fprintln(msg, "❌ Error: --range and --step must be positive")
+		os.Exit(1)
+	}
+
+	results := []queryResult{}
+	forEachTarget(tf, func(t Target) {
+		name := t.Name
+		var resp *PromResponse
+		var err error
+		if *rangeDur > 0 {
+			end := time.Now()
+			resp, err = queryPrometheusRange(context.Background(), t, promQuery, end.Add(-*rangeDur), end, *step)
+		} else {
+			resp, err = queryPrometheus(context.Background(), t, promQuery)
+		}
+
+		if *asJSON {
+			res := queryResult{Target: name}
+			if err != nil {
+				res.Error = err.Error()
+			} else {
+				res.ResultType, res.Result = resp.Data.ResultType, resp.Data.Result
+			}
+			results = append(results, res)
+			return
+		}
+
+		fmt.Printf("\n🌐 %s\n", name)
+		if err != nil {
+			fmt.Printf("   ❌ %v\n", err)
+			return
+		}
+		printQueryResult(resp)
+	})
+
+	if *asJSON {
+		out, _ := json.MarshalIndent(results, "", "  ")
+		fmt.Println(string(out))
+	}
+}
+
+func printQueryResult(resp *PromResponse) {
+	if len(resp.Data.Result) == 0 {
+		fmt.Println("   (no series)")
+		return
+	}
+
+	if resp.Data.ResultType == "matrix" {
+		for _, series := range resp.Data.Result {
+			values := seriesValues(series.Values)
+			fmt.Printf("   %s\n", formatMetric(series.Metric))
+			if len(values) == 0 {
+				fmt.Println("      (no samples)")
+				continue
+			}
+			min, max := minMax(values)
+			fmt.Printf("      %s  min=%s max=%s last=%s\n", sparkline(values, sparklineWidth),
+				formatValue(min), formatValue(max), formatValue(values[len(values)-1]))
+		}
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "   SERIES\tVALUE")
+	for _, series := range resp.Data.Result {
+		value := ""
+		if len(series.Value) == 2 {
+			value = fmt.Sprint(series.Value[1])
+		}
+		fmt.Fprintf(w, "   %s\t%s\n", formatMetric(series.Metric), value)
+	}
+	w.Flush()
+}
+
+// seriesValues extracts the float samples of a matrix series
+func seriesValues(samples [][]interface{}) []float64 {
+	values := make([]float64, 0, len(samples))
+	for _, s := range samples {
+		if len(s) != 2 {
+			continue
+		}
+		v, err := strconv.ParseFloat(fmt.Sprint(s[1]), 64)
+		if err != nil {
+			continue
+		}
+		values = append(values, v)
+	}
+	return values
+}
+
+// sparkline renders values as block characters, averaging them into at
+// most width buckets. NaN and infinite samples are drawn as spaces.
+func sparkline(values []float64, width int) string {
+	if len(values) > width {
+		buckets := make([]float64, width)
+		for i := range buckets {
+			from := i * len(values) / width
+			to := (i + 1) * len(values) / width
+			sum := 0.0
+			for _, v := range values[from:to] {
+				sum += v
+			}
+			buckets[i] = sum / float64(to-from)
+		}
+		values = buckets
+	}
+
+	min, max := minMax(values)
+	var sb strings.Builder
+	for _, v := range values {
+		switch {
+		case math.IsNaN(v) || math.IsInf(v, 0):
+			sb.WriteRune(' ')
+		case max == min:
+			sb.WriteRune(sparkChars[len(sparkChars)/2])
+		default:
+			idx := int((v - min) / (max - min) * float64(len(sparkChars)-1))
+			sb.WriteRune(sparkChars[idx])
+		}
+	}
+	return sb.String()
+}
+
+// minMax returns the smallest and largest finite value
+func minMax(values []float64) (float64, float64) {
+	min, max := math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			continue
+		}
+		min = math.Min(min, v)
+		max = math.Max(max, v)
+	}
+	if math.IsInf(min, 1) {
+		return math.NaN(), math.NaN()
+	}
+	return min, max
+}
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', 6, 64)
+}
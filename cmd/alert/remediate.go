@@ -0,0 +1,244 @@
+package alert
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Action is a named remediation a rule offers for its alert, e.g. deleting
+// a crashlooping pod. It only ever runs after an explicit confirmation.
+type Action struct {
+	Name        string
+	Description string
+	// Command renders the shell command for an alert, or fails when the
+	// alert lacks something the action needs
+	Command func(alert Alert) (string, error)
+	// Unbound explains why the command can't be pinned to a target, e.g.
+	// a CLI that talks to the server it is logged in to; nil when it can
+	Unbound func(t Target) string
+}
+
+// RemediationConfig is the remediation section of config.yaml
+//
+//	alert:
+//	  remediation:
+//	    allow:
+//	      - delete-pod          # on every target
+//	      - "staging-*:*"       # every action on staging clusters
+type RemediationConfig struct {
+	// Allow lists "action" or "target:action" globs. Actions that match no
+	// entry are shown but never offered.
+	Allow []string `yaml:"allow"`
+}
+
+// --- Built-in Actions ---
+
+var (
+	deletePodAction = Action{
+		Name:        "delete-pod",
+		Description: "Delete the pod so its controller recreates it",
+		Command:     labelCommand("kubectl delete pod -n %s %s", "namespace", "pod"),
+	}
+	cordonNodeAction = Action{
+		Name:        "cordon-node",
+		Description: "Cordon the node so no new pods are scheduled on it",
+		Command:     labelCommand("kubectl cordon %s", "node"),
+	}
+	veleroBackupAction = Action{
+		Name:        "velero-backup",
+		Description: "Trigger a new backup from the failing schedule",
+		// velero is pinned to the target's context like kubectl
+		Command: labelCommand("velero backup create --from-schedule %s", "schedule"),
+	}
+	argoSyncAction = Action{
+		Name:        "argocd-sync",
+		Description: "Sync the application",
+		Command:     labelCommand("argocd app sync %s", "name"),
+		Unbound:     argocdUnbound,
+	}
+)
+
+// argocdUnbound only allows argocd over SSH: locally it would sync on
+// whichever Argo CD server the CLI is logged in to, not the target's
+func argocdUnbound(t Target) string {
+	if t.IsSSH() {
+		return ""
+	}
+	return fmt.Sprintf("argocd uses the server it is logged in to, not %s; only offered on SSH targets", t.Name)
+}
+
+// labelCommand builds an Action command from a format string whose verbs
+// are filled with the shell-quoted values of the given labels
+func labelCommand(format string, names ...string) func(Alert) (string, error) {
+	return func(alert Alert) (string, error) {
+		values := make([]interface{}, len(names))
+		for i, name := range names {
+			v := alert.Labels[name]
+			if v == "" {
+				return "", fmt.Errorf("alert has no '%s' label", name)
+			}
+			values[i] = shellQuote(v)
+		}
+		return fmt.Sprintf(format, values...), nil
+	}
+}
+
+// --- Interactive Remediation ---
+
+// remediator offers the actions of diagnosed alerts and runs the confirmed
+// ones through runCommand, so they work locally and over SSH
+type remediator struct {
+	allow  []string
+	dryRun bool
+	in     *bufio.Reader
+}
+
+func newRemediator(cfg RemediationConfig, dryRun bool) *remediator {
+	return &remediator{allow: cfg.Allow, dryRun: dryRun, in: bufio.NewReader(os.Stdin)}
+}
+
+// allowed reports whether the allowlist permits the action on the target
+func (r *remediator) allowed(t Target, action string) bool {
+	for _, entry := range r.allow {
+		targetGlob, actionGlob := "*", entry
+		if i := strings.LastIndex(entry, ":"); i >= 0 {
+			targetGlob, actionGlob = entry[:i], entry[i+1:]
+		}
+		okTarget, _ := path.Match(targetGlob, t.Name)
+		okAction, _ := path.Match(actionGlob, action)
+		if okTarget && okAction {
+			return true
+		}
+	}
+	return false
+}
+
+// offered is one action rendered for one alert
+type offered struct {
+	action  Action
+	command string
+	reason  string // why it can't be run; empty when it can
+}
+
+// offer walks the diagnosed alerts of a target and returns the commands
+// that were run
+func (r *remediator) offer(t Target, jobs []*diagJob) []CommandRecord {
+	var records []CommandRecord
+	done := make(map[string]bool)
+
+	for _, job := range jobs {
+		if len(job.rule.Actions) == 0 {
+			continue
+		}
+
+		var options []offered
+		for _, a := range job.rule.Actions {
+			o := offered{action: a}
+			cmd, err := a.Command(job.alert)
+			switch {
+			case err != nil:
+				o.reason = err.Error()
+			case a.Unbound != nil && a.Unbound(t) != "":
+				o.command, o.reason = cmd, a.Unbound(t)
+			case done[cmd]:
+				o.command, o.reason = cmd, "already run"
+			case !r.allowed(t, a.Name):
+				o.command, o.reason = cmd, "not in alert.remediation.allow"
+			default:
+				o.command = cmd
+			}
+			options = append(options, o)
+		}
+
+		fmt.Printf("\n🛠  Remediation for %s → %s on %s\n", job.rule.Name, alertTarget(job.alert), t.Name)
+		runnable := 0
+		for i, o := range options {
+			fmt.Printf("   [%d] %-15s %s\n", i+1, o.action.Name, o.action.Description)
+			if o.command != "" {
+				fmt.Printf("       $ %s\n", o.command)
+			}
+			if o.reason != "" {
+				fmt.Printf("       ⛔ %s\n", o.reason)
+			} else {
+				runnable++
+			}
+		}
+
+		if r.dryRun || runnable == 0 {
+			if r.dryRun {
+				fmt.Println("   (dry run, nothing executed)")
+			}
+			continue
+		}
+
+		o, ok := r.choose(options)
+		if !ok {
+			continue
+		}
+		if !r.confirm(t, o.command) {
+			fmt.Println("   ⏭️  Skipped")
+			continue
+		}
+
+		done[o.command] = true
+		records = append(records, r.run(t, o.command))
+	}
+	return records
+}
+
+// choose asks which action to run; Enter skips
+func (r *remediator) choose(options []offered) (offered, bool) {
+	for {
+		fmt.Printf("   Choose an action [1-%d, Enter to skip]: ", len(options))
+		line, err := r.in.ReadString('\n')
+		line = strings.TrimSpace(line)
+		if line == "" || err != nil {
+			return offered{}, false
+		}
+
+		n, convErr := strconv.Atoi(line)
+		if convErr != nil || n < 1 || n > len(options) {
+			fmt.Println("   ❌ Invalid choice")
+			continue
+		}
+		if reason := options[n-1].reason; reason != "" {
+			fmt.Printf("   ❌ Not available: %s\n", reason)
+			continue
+		}
+		return options[n-1], true
+	}
+}
+
+// confirm shows the exact command and requires the word "yes"
+func (r *remediator) confirm(t Target, command string) bool {
+	where := t.Name
+	if t.IsSSH() {
+		where = t.Server + " (as root)"
+	}
+	fmt.Printf("   ⚠️  About to run on %s:\n       %s\n   Type 'yes' to confirm: ", where, command)
+	line, _ := r.in.ReadString('\n')
+	return strings.TrimSpace(line) == "yes"
+}
+
+func (r *remediator) run(t Target, command string) CommandRecord {
+	start := time.Now()
+	output, err := runCommand(t, command)
+	rec := newCommandRecord(command, start, err)
+
+	for _, line := range strings.Split(strings.TrimRight(strings.ReplaceAll(string(output), "\r", ""), "\n"), "\n") {
+		if line != "" {
+			fmt.Printf("      %s\n", line)
+		}
+	}
+	if err != nil {
+		fmt.Printf("   ❌ Failed (exit %d): %v\n", rec.ExitCode, err)
+	} else {
+		fmt.Println("   ✅ Done")
+	}
+	return rec
+}
@@ -0,0 +1,279 @@
+package alert
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Report is everything `god alert details --report` writes: the alerts of
+// every target and the output and commands of each diagnosis
+type Report struct {
+	Started  time.Time
+	Finished time.Time
+	Targets  string
+	Clusters []*ReportCluster
+}
+
+// ReportCluster is the part of a Report about one target
+type ReportCluster struct {
+	Name      string
+	Kind      string
+	Time      time.Time
+	Error     string
+	Alerts    []Alert
+	Diagnoses []ReportDiagnosis
+	// Commands are the ones that fetched the alerts
+	Commands []CommandRecord
+	// Remediations are the actions confirmed with --remediate
+	Remediations []CommandRecord
+}
+
+// ReportDiagnosis is one rule run
+type ReportDiagnosis struct {
+	Rule     string
+	Alert    string
+	Target   string
+	Started  time.Time
+	Duration time.Duration
+	Output   string
+	Commands []CommandRecord
+}
+
+// reportFormat returns "md" or "html" from the file extension
+func reportFormat(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".md", ".markdown":
+		return "md", nil
+	case ".html", ".htm":
+		return "html", nil
+	}
+	return "", fmt.Errorf("unsupported report file '%s' (expected .md or .html)", path)
+}
+
+// addDiagnoses records the finished jobs of a target
+func (c *ReportCluster) addDiagnoses(jobs []*diagJob) {
+	for _, j := range jobs {
+		c.Diagnoses = append(c.Diagnoses, ReportDiagnosis{
+			Rule:     j.rule.Name,
+			Alert:    j.alert.Labels["alertname"],
+			Target:   alertTarget(j.alert),
+			Started:  j.diag.Started,
+			Duration: j.diag.Finished.Sub(j.diag.Started),
+			Output:   strings.Trim(strings.ReplaceAll(j.diag.Output(), "\r", ""), "\n"),
+			Commands: j.diag.Commands,
+		})
+	}
+}
+
+// writeReport renders the report in the format given by the file extension
+func writeReport(path string, r *Report) error {
+	format, err := reportFormat(path)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if format == "html" {
+		err = htmlReportTemplate.Execute(f, r)
+	} else {
+		err = r.writeMarkdown(f)
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// --- Markdown ---
+
+func (r *Report) writeMarkdown(w io.Writer) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Incident report %s\n\n", r.Started.Format("2006-01-02 15:04 MST"))
+	fmt.Fprintf(&b, "- **Targets:** %s\n", r.Targets)
+	fmt.Fprintf(&b, "- **Started:** %s\n", r.Started.Format(time.RFC3339))
+	fmt.Fprintf(&b, "- **Finished:** %s (%s)\n\n", r.Finished.Format(time.RFC3339), r.Finished.Sub(r.Started).Round(time.Second))
+
+	b.WriteString("## Contents\n\n")
+	for i, c := range r.Clusters {
+		fmt.Fprintf(&b, "- [%s](#%s) — %s\n", c.Name, c.anchor(i), c.Summary())
+		for j, d := range c.Diagnoses {
+			fmt.Fprintf(&b, "  - [%s → %s](#%s)\n", d.Rule, d.Target, diagAnchor(i, j))
+		}
+	}
+
+	for i, c := range r.Clusters {
+		fmt.Fprintf(&b, "\n<a id=\"%s\"></a>\n\n## %s\n\n", c.anchor(i), c.Name)
+		fmt.Fprintf(&b, "_%s, checked at %s_\n\n", c.Kind, c.Time.Format(time.RFC3339))
+
+		if len(c.Commands) > 0 {
+			b.WriteString("Alerts fetched with:\n\n")
+			mdCommands(&b, c.Commands, func(cmd CommandRecord) string {
+				return fmt.Sprintf("exit %d, %s", cmd.ExitCode, cmd.Duration.Round(time.Millisecond))
+			})
+			b.WriteString("\n")
+		}
+		if c.Error != "" {
+			fmt.Fprintf(&b, "**Could not fetch alerts:** %s\n", c.Error)
+			continue
+		}
+		if len(c.Alerts) == 0 {
+			b.WriteString("No active alerts.\n")
+			continue
+		}
+
+		b.WriteString("| Alert | Severity | Target | Since | Summary |\n| --- | --- | --- | --- | --- |\n")
+		for _, a := range c.Alerts {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n", mdCell(a.Labels["alertname"]), mdCell(a.Labels["severity"]),
+				mdCell(alertTarget(a)), mdCell(a.StartsAt), mdCell(a.Annotations["summary"]))
+		}
+
+		for j, d := range c.Diagnoses {
+			fmt.Fprintf(&b, "\n<a id=\"%s\"></a>\n\n### %s → %s\n\n", diagAnchor(i, j), d.Rule, d.Target)
+			fmt.Fprintf(&b, "_Started %s, took %s_\n\n", d.Started.Format("15:04:05"), d.Duration.Round(time.Millisecond))
+
+			if len(d.Commands) > 0 {
+				mdCommands(&b, d.Commands, func(cmd CommandRecord) string {
+					return fmt.Sprintf("exit %d, %s", cmd.ExitCode, cmd.Duration.Round(time.Millisecond))
+				})
+				b.WriteString("\n")
+			}
+			if d.Output != "" {
+				mdCodeBlock(&b, "", d.Output)
+			}
+		}
+
+		if len(c.Remediations) > 0 {
+			b.WriteString("\n### Remediation\n\n")
+			mdCommands(&b, c.Remediations, func(cmd CommandRecord) string {
+				return fmt.Sprintf("%s, exit %d", cmd.Started.Format("15:04:05"), cmd.ExitCode)
+			})
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func (c *ReportCluster) anchor(i int) string {
+	return fmt.Sprintf("cluster-%d", i+1)
+}
+
+func diagAnchor(cluster, diag int) string {
+	return fmt.Sprintf("cluster-%d-diag-%d", cluster+1, diag+1)
+}
+
+// Summary is the one-line status shown in the table of contents
+func (c *ReportCluster) Summary() string {
+	switch {
+	case c.Error != "":
+		return "fetch failed"
+	case len(c.Alerts) == 0:
+		return "no active alerts"
+	}
+	return fmt.Sprintf("%d alerts, %d diagnoses", len(c.Alerts), len(c.Diagnoses))
+}
+
+// mdCell makes a value safe for a Markdown table cell
+func mdCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// mdCommands writes commands verbatim into one shell code block, each
+// preceded by a comment line from note
+func mdCommands(b *strings.Builder, cmds []CommandRecord, note func(CommandRecord) string) {
+	var code strings.Builder
+	for i, cmd := range cmds {
+		if i > 0 {
+			code.WriteString("\n")
+		}
+		fmt.Fprintf(&code, "# %s\n%s\n", note(cmd), cmd.Command)
+	}
+	mdCodeBlock(b, "sh", strings.TrimSuffix(code.String(), "\n"))
+}
+
+// mdCodeBlock fences text with more backticks than it contains in a row
+func mdCodeBlock(b *strings.Builder, lang, text string) {
+	fence := "```"
+	for strings.Contains(text, fence) {
+		fence += "`"
+	}
+	fmt.Fprintf(b, "%s%s\n%s\n%s\n", fence, lang, text, fence)
+}
+
+// --- HTML ---
+
+var htmlReportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"anchor":     func(c *ReportCluster, i int) string { return c.anchor(i) },
+	"diagAnchor": diagAnchor,
+	"target":     alertTarget,
+	"rfc3339":    func(t time.Time) string { return t.Format(time.RFC3339) },
+	"clock":      func(t time.Time) string { return t.Format("15:04:05") },
+	"round":      func(d time.Duration) time.Duration { return d.Round(time.Millisecond) },
+	"elapsed":    func(a, b time.Time) time.Duration { return b.Sub(a).Round(time.Second) },
+}).Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8">
+<title>Incident report {{ .Started.Format "2006-01-02 15:04 MST" }}</title>
+<style>
+body { font-family: sans-serif; max-width: 1100px; margin: 2em auto; color: #222; }
+table { border-collapse: collapse; margin: 0.5em 0; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+th { background: #f4f4f4; }
+pre { background: #f8f8f8; border: 1px solid #ddd; padding: 8px; overflow-x: auto; }
+code { font-size: 0.9em; white-space: pre-wrap; }
+.fail { color: #b00; font-weight: bold; }
+.meta { color: #666; font-style: italic; }
+</style></head>
+<body>
+<h1>Incident report {{ .Started.Format "2006-01-02 15:04 MST" }}</h1>
+<ul>
+<li><b>Targets:</b> {{ .Targets }}</li>
+<li><b>Started:</b> {{ rfc3339 .Started }}</li>
+<li><b>Finished:</b> {{ rfc3339 .Finished }} ({{ elapsed .Started .Finished }})</li>
+</ul>
+
+<h2>Contents</h2>
+<ul>
+{{ range $i, $c := .Clusters }}<li><a href="#{{ anchor $c $i }}">{{ $c.Name }}</a> — {{ $c.Summary }}
+{{ if $c.Diagnoses }}<ul>{{ range $j, $d := $c.Diagnoses }}<li><a href="#{{ diagAnchor $i $j }}">{{ $d.Rule }} → {{ $d.Target }}</a></li>{{ end }}</ul>{{ end }}
+</li>
+{{ end }}</ul>
+
+{{ range $i, $c := .Clusters }}
+<h2 id="{{ anchor $c $i }}">{{ $c.Name }}</h2>
+<p class="meta">{{ $c.Kind }}, checked at {{ rfc3339 $c.Time }}</p>
+{{ if $c.Commands }}<p>Alerts fetched with:</p>
+<table>
+<tr><th>Command</th><th>Exit</th><th>Duration</th></tr>
+{{ range $c.Commands }}<tr><td><code>{{ .Command }}</code></td><td{{ if .ExitCode }} class="fail"{{ end }}>{{ .ExitCode }}</td><td>{{ round .Duration }}</td></tr>
+{{ end }}</table>{{ end }}
+{{ if $c.Error }}<p class="fail">Could not fetch alerts: {{ $c.Error }}</p>
+{{ else if not $c.Alerts }}<p>No active alerts.</p>
+{{ else }}<table>
+<tr><th>Alert</th><th>Severity</th><th>Target</th><th>Since</th><th>Summary</th></tr>
+{{ range $c.Alerts }}<tr><td>{{ index .Labels "alertname" }}</td><td>{{ index .Labels "severity" }}</td><td>{{ target . }}</td><td>{{ .StartsAt }}</td><td>{{ index .Annotations "summary" }}</td></tr>
+{{ end }}</table>
+{{ range $j, $d := $c.Diagnoses }}
+<h3 id="{{ diagAnchor $i $j }}">{{ $d.Rule }} → {{ $d.Target }}</h3>
+<p class="meta">Started {{ clock $d.Started }}, took {{ round $d.Duration }}</p>
+{{ if $d.Commands }}<table>
+<tr><th>Command</th><th>Exit</th><th>Duration</th></tr>
+{{ range $d.Commands }}<tr><td><code>{{ .Command }}</code></td><td{{ if .ExitCode }} class="fail"{{ end }}>{{ .ExitCode }}</td><td>{{ round .Duration }}</td></tr>
+{{ end }}</table>{{ end }}
+{{ if $d.Output }}<pre>{{ $d.Output }}</pre>{{ end }}
+{{ end }}{{ if $c.Remediations }}<h3>Remediation</h3>
+<table>
+<tr><th>Time</th><th>Command</th><th>Exit</th></tr>
+{{ range $c.Remediations }}<tr><td>{{ clock .Started }}</td><td><code>{{ .Command }}</code></td><td{{ if .ExitCode }} class="fail"{{ end }}>{{ .ExitCode }}</td></tr>
+{{ end }}</table>{{ end }}{{ end }}{{ end }}
+</body></html>
+`))
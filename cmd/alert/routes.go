@@ -0,0 +1,490 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"god/cmd/config"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Alertmanager's defaults for routes that don't set their timings
+const (
+	defaultGroupWait      = "30s"
+	defaultGroupInterval  = "5m"
+	defaultRepeatInterval = "4h"
+)
+
+// amStatus is the part of /api/v2/status god reads
+type amStatus struct {
+	Config struct {
+		Original string `json:"original"`
+	} `json:"config"`
+	VersionInfo struct {
+		Version string `json:"version"`
+	} `json:"versionInfo"`
+}
+
+// amConfig is the part of alertmanager.yml god evaluates
+type amConfig struct {
+	Route        *amRoute        `yaml:"route"`
+	InhibitRules []amInhibitRule `yaml:"inhibit_rules"`
+	Receivers    []struct {
+		Name string `yaml:"name"`
+	} `yaml:"receivers"`
+}
+
+// amRoute is a node of the routing tree
+type amRoute struct {
+	Receiver            string            `yaml:"receiver"`
+	GroupBy             []string          `yaml:"group_by"`
+	Continue            bool              `yaml:"continue"`
+	Match               map[string]string `yaml:"match"`
+	MatchRE             map[string]string `yaml:"match_re"`
+	Matchers            []string          `yaml:"matchers"`
+	GroupWait           string            `yaml:"group_wait"`
+	GroupInterval       string            `yaml:"group_interval"`
+	RepeatInterval      string            `yaml:"repeat_interval"`
+	MuteTimeIntervals   []string          `yaml:"mute_time_intervals"`
+	ActiveTimeIntervals []string          `yaml:"active_time_intervals"`
+	Routes              []*amRoute        `yaml:"routes"`
+
+	matchers []*Matcher
+	// effective holds the settings after inheriting from the parent
+	effective amRouteSettings
+}
+
+type amRouteSettings struct {
+	receiver                                 string
+	groupBy                                  []string
+	groupWait, groupInterval, repeatInterval string
+}
+
+// amInhibitRule mutes alerts matching the target while an alert matching the
+// source fires with the same values of the equal labels
+type amInhibitRule struct {
+	SourceMatch    map[string]string `yaml:"source_match"`
+	SourceMatchRE  map[string]string `yaml:"source_match_re"`
+	SourceMatchers []string          `yaml:"source_matchers"`
+	TargetMatch    map[string]string `yaml:"target_match"`
+	TargetMatchRE  map[string]string `yaml:"target_match_re"`
+	TargetMatchers []string          `yaml:"target_matchers"`
+	Equal          []string          `yaml:"equal"`
+
+	source, target []*Matcher
+}
+
+// routesFlags holds the flags of god alert routes
+type routesFlags struct {
+	*flag.FlagSet
+	targets   *targetFlags
+	endpoints *endpointFlags
+}
+
+func newRoutesFlags() *routesFlags {
+	f := &routesFlags{FlagSet: flag.NewFlagSet("routes", flag.ExitOnError)}
+	f.targets = addTargetFlags(f.FlagSet)
+	f.endpoints = addEndpointFlags(f.FlagSet, true)
+	return f
+}
+
+func runRoutes(args []string) {
+	f := newRoutesFlags()
+	tf, ef := f.targets, f.endpoints
+
+	test := len(args) > 0 && args[0] == "test"
+	if test {
+		args = args[1:]
+	}
+	config.Apply(f.FlagSet, "alert")
+	labelArgs := parseInterleaved(f.FlagSet, args)
+	configureEndpoints(ef)
+
+	var labels map[string]string
+	if test {
+		var err error
+		if labels, err = parseLabelArgs(labelArgs); err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+			os.Exit(1)
+		}
+	} else if len(labelArgs) > 0 {
+		fmt.Printf("❌ Error: Unexpected argument '%s' (did you mean god alert routes test %s?)\n", labelArgs[0], strings.Join(labelArgs, " "))
+		os.Exit(1)
+	}
+
+	failed := false
+	forEachTarget(tf, func(t Target) {
+		fmt.Printf("\n🌐 %s\n", t.Name)
+		cfg, version, err := fetchAlertmanagerConfig(t)
+		if err != nil {
+			fmt.Printf("   ❌ %v\n", err)
+			failed = true
+			return
+		}
+		if !test {
+			fmt.Printf("   Alertmanager %s, %d receivers, %d inhibition rules\n\n", version, len(cfg.Receivers), len(cfg.InhibitRules))
+			printRouteTree(cfg.Route, "   ", "   ", true)
+			return
+		}
+		testRoute(t, cfg, labels)
+	})
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// parseLabelArgs parses name=value arguments into a label set
+func parseLabelArgs(args []string) (map[string]string, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("missing labels, e.g. god alert routes test severity=critical team=db")
+	}
+	labels := make(map[string]string)
+	for _, a := range args {
+		m, err := ParseMatcher(a)
+		if err != nil || m.Op != "=" {
+			return nil, fmt.Errorf("invalid label '%s' (expected name=value)", a)
+		}
+		labels[m.Name] = m.Value
+	}
+	return labels, nil
+}
+
+// fetchAlertmanagerConfig reads the running configuration from the status API
+func fetchAlertmanagerConfig(t Target) (*amConfig, string, error) {
+	body, err := alertmanagerGet(context.Background(), t, "/api/v2/status")
+	if err != nil {
+		return nil, "", err
+	}
+	var status amStatus
+	if err := json.Unmarshal(body, &status); err != nil {
+		return nil, "", fmt.Errorf("failed to parse Alertmanager status: %v", err)
+	}
+
+	var cfg amConfig
+	if err := yaml.Unmarshal([]byte(status.Config.Original), &cfg); err != nil {
+		return nil, "", fmt.Errorf("failed to parse Alertmanager config: %v", err)
+	}
+	if cfg.Route == nil {
+		return nil, "", fmt.Errorf("alertmanager config has no route")
+	}
+	if err := cfg.Route.compile(nil); err != nil {
+		return nil, "", err
+	}
+	for i := range cfg.InhibitRules {
+		if err := cfg.InhibitRules[i].compile(); err != nil {
+			return nil, "", fmt.Errorf("inhibit rule %d: %v", i+1, err)
+		}
+	}
+	return &cfg, status.VersionInfo.Version, nil
+}
+
+// compile parses the matchers and resolves the settings inherited from parent
+func (r *amRoute) compile(parent *amRoute) error {
+	var err error
+	if r.matchers, err = amMatchers(r.Match, r.MatchRE, r.Matchers); err != nil {
+		return err
+	}
+
+	e := amRouteSettings{groupWait: defaultGroupWait, groupInterval: defaultGroupInterval, repeatInterval: defaultRepeatInterval}
+	if parent != nil {
+		e = parent.effective
+	}
+	if r.Receiver != "" {
+		e.receiver = r.Receiver
+	}
+	if r.GroupBy != nil {
+		e.groupBy = r.GroupBy
+	}
+	for _, s := range []struct{ own, eff *string }{
+		{&r.GroupWait, &e.groupWait}, {&r.GroupInterval, &e.groupInterval}, {&r.RepeatInterval, &e.repeatInterval},
+	} {
+		if *s.own != "" {
+			*s.eff = *s.own
+		}
+	}
+	r.effective = e
+
+	for _, c := range r.Routes {
+		if err := c.compile(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *amInhibitRule) compile() error {
+	var err error
+	if r.source, err = amMatchers(r.SourceMatch, r.SourceMatchRE, r.SourceMatchers); err != nil {
+		return err
+	}
+	r.target, err = amMatchers(r.TargetMatch, r.TargetMatchRE, r.TargetMatchers)
+	return err
+}
+
+// amMatchers combines the legacy match/match_re maps with matchers, whose
+// items may each hold several comma-separated matchers, optionally in braces
+func amMatchers(match, matchRE map[string]string, items []string) ([]*Matcher, error) {
+	var ms []*Matcher
+	for _, k := range sortedKeys(match) {
+		m, _ := newMatcher(k, "=", match[k])
+		ms = append(ms, m)
+	}
+	for _, k := range sortedKeys(matchRE) {
+		m, err := newMatcher(k, "=~", matchRE[k])
+		if err != nil {
+			return nil, fmt.Errorf("invalid match_re %s: %v", k, err)
+		}
+		ms = append(ms, m)
+	}
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		item = strings.TrimSuffix(strings.TrimPrefix(item, "{"), "}")
+		for _, part := range splitMatchers(item) {
+			m, err := ParseMatcher(part)
+			if err != nil {
+				return nil, err
+			}
+			ms = append(ms, m)
+		}
+	}
+	return ms, nil
+}
+
+// splitMatchers splits on commas outside of double quotes
+func splitMatchers(s string) []string {
+	var parts []string
+	inQuotes, escaped, start := false, false, 0
+	for i, c := range s {
+		switch {
+		case escaped:
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == '"':
+			inQuotes = !inQuotes
+		case c == ',' && !inQuotes:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	parts = append(parts, s[start:])
+
+	var out []string
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// --- Tree ---
+
+// describe renders the matchers of a route, e.g. {severity="critical"}
+func (r *amRoute) describe() string {
+	if len(r.matchers) == 0 {
+		return "(all)"
+	}
+	return formatMatchers(r.matchers)
+}
+
+// options lists what the route sets itself, as opposed to inheriting
+func (r *amRoute) options() string {
+	var opts []string
+	if r.Continue {
+		opts = append(opts, "continue")
+	}
+	if r.GroupBy != nil {
+		opts = append(opts, "group_by ["+strings.Join(r.GroupBy, ", ")+"]")
+	}
+	if r.GroupWait != "" {
+		opts = append(opts, "wait "+r.GroupWait)
+	}
+	if r.GroupInterval != "" {
+		opts = append(opts, "interval "+r.GroupInterval)
+	}
+	if r.RepeatInterval != "" {
+		opts = append(opts, "repeat "+r.RepeatInterval)
+	}
+	if len(r.MuteTimeIntervals) > 0 {
+		opts = append(opts, "muted during "+strings.Join(r.MuteTimeIntervals, ", "))
+	}
+	if len(r.ActiveTimeIntervals) > 0 {
+		opts = append(opts, "active during "+strings.Join(r.ActiveTimeIntervals, ", "))
+	}
+	if len(opts) == 0 {
+		return ""
+	}
+	return "  (" + strings.Join(opts, ", ") + ")"
+}
+
+// printRouteTree prints a route after head and its children indented by lead
+func printRouteTree(r *amRoute, head, lead string, root bool) {
+	name := r.describe()
+	if root {
+		name = "root"
+	}
+	receiver := r.effective.receiver
+	if r.Receiver == "" && !root {
+		receiver += " (inherited)"
+	}
+	fmt.Printf("%s%s → 📨 %s%s\n", head, name, receiver, r.options())
+
+	for i, c := range r.Routes {
+		if i == len(r.Routes)-1 {
+			printRouteTree(c, lead+"└── ", lead+"    ", false)
+		} else {
+			printRouteTree(c, lead+"├── ", lead+"│   ", false)
+		}
+	}
+}
+
+// --- Test ---
+
+// routeMatch is a route an alert ends up at, with the path leading to it
+type routeMatch struct {
+	route *amRoute
+	path  []*amRoute
+}
+
+// match walks the tree the way Alertmanager does: the first matching child
+// wins unless it sets continue, and a node whose children all miss is
+// itself the match
+func (r *amRoute) match(labels map[string]string, path []*amRoute) []routeMatch {
+	if !matchesAll(r.matchers, labels) {
+		return nil
+	}
+	path = append(path[:len(path):len(path)], r)
+
+	var matches []routeMatch
+	for _, c := range r.Routes {
+		m := c.match(labels, path)
+		matches = append(matches, m...)
+		if len(m) > 0 && !c.Continue {
+			break
+		}
+	}
+	if len(matches) == 0 {
+		matches = []routeMatch{{route: r, path: path}}
+	}
+	return matches
+}
+
+func testRoute(t Target, cfg *amConfig, labels map[string]string) {
+	fmt.Printf("   🏷️  %s\n\n", formatMetric(labels))
+
+	fmt.Println("   🎯 Routes:")
+	for i, m := range cfg.Route.match(labels, nil) {
+		var steps []string
+		for j, r := range m.path {
+			if j == 0 {
+				steps = append(steps, "root")
+			} else {
+				steps = append(steps, r.describe())
+			}
+		}
+		e := m.route.effective
+		groupBy := "[" + strings.Join(e.groupBy, ", ") + "]"
+		if len(e.groupBy) == 0 {
+			groupBy = "none"
+		}
+		fmt.Printf("   %d. 📨 %s\n", i+1, e.receiver)
+		fmt.Printf("      via %s\n", strings.Join(steps, " → "))
+		fmt.Printf("      group_by %s, wait %s, interval %s, repeat %s\n", groupBy, e.groupWait, e.groupInterval, e.repeatInterval)
+		if len(m.route.MuteTimeIntervals) > 0 {
+			fmt.Printf("      🔕 Muted during %s\n", strings.Join(m.route.MuteTimeIntervals, ", "))
+		}
+		if len(m.route.ActiveTimeIntervals) > 0 {
+			fmt.Printf("      🕒 Only active during %s\n", strings.Join(m.route.ActiveTimeIntervals, ", "))
+		}
+	}
+
+	fmt.Println("\n   🔇 Inhibition:")
+	var rules []*amInhibitRule
+	for i := range cfg.InhibitRules {
+		if matchesAll(cfg.InhibitRules[i].target, labels) {
+			rules = append(rules, &cfg.InhibitRules[i])
+		}
+	}
+	if len(rules) == 0 {
+		fmt.Println("   ✅ No inhibition rule targets these labels")
+		return
+	}
+
+	// Every firing alert can inhibit, even silenced or inhibited ones
+	var firing []Alert
+	body, err := alertmanagerGet(context.Background(), t, "/api/v2/alerts?active=true")
+	if err == nil {
+		err = json.Unmarshal(body, &firing)
+	}
+	if err != nil {
+		fmt.Printf("   ⚠️  Could not fetch firing alerts to check sources: %v\n", err)
+	}
+
+	for _, r := range rules {
+		same := ""
+		if len(r.Equal) > 0 {
+			same = " with the same " + strings.Join(r.Equal, ", ")
+		}
+		sources := r.activeSources(labels, firing)
+		if len(sources) == 0 {
+			fmt.Printf("   ⚠️  Would be suppressed while an alert matching %s fires%s\n", formatMatchers(r.source), same)
+			continue
+		}
+		fmt.Printf("   ⛔ Suppressed now by %s (matching %s%s)\n", sourceNames(sources), formatMatchers(r.source), same)
+	}
+}
+
+// activeSources returns the firing alerts that inhibit labels under the rule.
+// As in Alertmanager, an alert matching both sides can't inhibit a target
+// that also matches both sides.
+func (r *amInhibitRule) activeSources(labels map[string]string, firing []Alert) []Alert {
+	twoSided := matchesAll(r.source, labels)
+	var sources []Alert
+	for _, a := range firing {
+		if !matchesAll(r.source, a.Labels) {
+			continue
+		}
+		if twoSided && matchesAll(r.target, a.Labels) {
+			continue
+		}
+		equal := true
+		for _, l := range r.Equal {
+			if a.Labels[l] != labels[l] {
+				equal = false
+				break
+			}
+		}
+		if equal {
+			sources = append(sources, a)
+		}
+	}
+	return sources
+}
+
+func formatMatchers(ms []*Matcher) string {
+	parts := make([]string, len(ms))
+	for i, m := range ms {
+		parts[i] = m.String()
+	}
+	return "{" + strings.Join(parts, ", ") + "}"
+}
+
+// sourceNames lists the distinct alert names of inhibiting alerts
+func sourceNames(alerts []Alert) string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, a := range alerts {
+		if n := a.Labels["alertname"]; !seen[n] {
+			seen[n] = true
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
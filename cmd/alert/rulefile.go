@@ -0,0 +1,404 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultStepTimeout  = 30 * time.Second
+	defaultStepMaxLines = 20
+)
+
+// RuleFile is the YAML document format of a file in rules.d, e.g.
+//
+//	rules:
+//	  - name: disk-pressure
+//	    alertname: NodeFilesystemAlmostOutOfSpace
+//	    matchers: ['severity="critical"']
+//	    steps:
+//	      - shell: df -h {{ .Labels.mountpoint }}
+//	      - promql: node_filesystem_avail_bytes{instance="{{ .Labels.instance }}"}
+//	    actions:
+//	      - name: clean-journal
+//	        description: Vacuum the systemd journal to 500M
+//	        shell: journalctl --vacuum-size=500M
+type RuleFile struct {
+	Rules []FileRule `yaml:"rules"`
+}
+
+// FileRule maps an alert name and/or label matchers to diagnostic steps
+type FileRule struct {
+	Name      string       `yaml:"name"`
+	AlertName string       `yaml:"alertname"`
+	Matchers  []string     `yaml:"matchers"`
+	Scope     string       `yaml:"scope"`     // "instance" (default) or "cluster"
+	DedupKey  string       `yaml:"dedup_key"` // template, defaults to the fingerprint
+	Steps     []RuleStep   `yaml:"steps"`
+	Actions   []RuleAction `yaml:"actions"`
+
+	source   string
+	matchers []*Matcher
+	dedup    *template.Template
+}
+
+// RuleStep is a single command of a FileRule. Exactly one of Shell, Kubectl
+// or PromQL must be set; each is a Go template rendered against the Alert,
+// with label and annotation values shell-quoted for Shell and Kubectl.
+type RuleStep struct {
+	Name     string        `yaml:"name"`
+	Shell    string        `yaml:"shell"`
+	Kubectl  string        `yaml:"kubectl"`
+	PromQL   string        `yaml:"promql"`
+	Timeout  time.Duration `yaml:"timeout"`
+	MaxLines int           `yaml:"max_lines"`
+
+	tmpl *template.Template
+}
+
+// RuleAction is a remediation of a FileRule. Exactly one of Shell or Kubectl
+// must be set; it is a Go template rendered against the Alert, with label and
+// annotation values shell-quoted.
+type RuleAction struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description"`
+	Shell       string `yaml:"shell"`
+	Kubectl     string `yaml:"kubectl"`
+
+	tmpl *template.Template
+}
+
+// fileRules holds the rules loaded from rules.d for this run
+var fileRules []*FileRule
+
+// templateFuncs are available inside step templates
+var templateFuncs = template.FuncMap{
+	"quote": quoteArg,
+	"raw":   rawArg,
+}
+
+// shellQuote wraps a value in single quotes for safe use in shell commands
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// shellArg is a label or annotation value in a shell or kubectl template.
+// It renders shell-quoted, so a value such as "$(reboot)" stays one argument.
+type shellArg string
+
+func (a shellArg) String() string {
+	return shellQuote(string(a))
+}
+
+// quoteArg shell-quotes a value, leaving an already quoted shellArg as is
+func quoteArg(v any) string {
+	if a, ok := v.(shellArg); ok {
+		return a.String()
+	}
+	return shellQuote(fmt.Sprint(v))
+}
+
+// rawArg returns a value unquoted, for templates that build a single
+// argument out of several values and quote the result themselves
+func rawArg(v any) string {
+	if a, ok := v.(shellArg); ok {
+		return string(a)
+	}
+	return fmt.Sprint(v)
+}
+
+// shellAlert is what shell and kubectl templates render against: the alert
+// with its label and annotation values quoted by default
+type shellAlert struct {
+	Alert
+	Labels      map[string]shellArg
+	Annotations map[string]shellArg
+}
+
+func newShellAlert(alert Alert) shellAlert {
+	quoted := func(values map[string]string) map[string]shellArg {
+		m := make(map[string]shellArg, len(values))
+		for k, v := range values {
+			m[k] = shellArg(v)
+		}
+		return m
+	}
+	return shellAlert{Alert: alert, Labels: quoted(alert.Labels), Annotations: quoted(alert.Annotations)}
+}
+
+// rulesDir returns the default location of YAML rule files
+func rulesDir() string {
+	dir := configDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "rules.d")
+}
+
+// loadRuleFiles reads every *.yaml / *.yml file in dir into fileRules.
+// A missing directory is not an error; broken files are reported and skipped.
+func loadRuleFiles(dir string) {
+	if dir == "" {
+		return
+	}
+
+	var paths []string
+	for _, pattern := range []string{"*.yaml", "*.yml"} {
+		matches, _ := filepath.Glob(filepath.Join(dir, pattern))
+		paths = append(paths, matches...)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		rules, err := parseRuleFile(path)
+		if err != nil {
+			fmt.Printf("⚠️  Skipping rule file %s: %v\n", path, err)
+			continue
+		}
+		fileRules = append(fileRules, rules...)
+	}
+
+	if len(fileRules) > 0 {
+		fmt.Printf("📜 Loaded %d diagnostic rules from %s\n", len(fileRules), dir)
+	}
+}
+
+func parseRuleFile(path string) ([]*FileRule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rf RuleFile
+	if err := yaml.Unmarshal(data, &rf); err != nil {
+		return nil, err
+	}
+
+	var rules []*FileRule
+	for i := range rf.Rules {
+		r := &rf.Rules[i]
+		r.source = filepath.Base(path)
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("%s#%d", r.source, i+1)
+		}
+		if r.AlertName == "" && len(r.Matchers) == 0 {
+			return nil, fmt.Errorf("rule %s: needs an alertname or matchers", r.Name)
+		}
+		if len(r.Steps) == 0 {
+			return nil, fmt.Errorf("rule %s: has no steps", r.Name)
+		}
+
+		if r.matchers, err = ParseMatchers(r.Matchers); err != nil {
+			return nil, fmt.Errorf("rule %s: %v", r.Name, err)
+		}
+
+		if r.Scope != "" && r.Scope != "instance" && r.Scope != "cluster" {
+			return nil, fmt.Errorf("rule %s: scope must be 'instance' or 'cluster'", r.Name)
+		}
+		if r.DedupKey != "" {
+			if r.dedup, err = template.New("dedup_key").Funcs(templateFuncs).Parse(r.DedupKey); err != nil {
+				return nil, fmt.Errorf("rule %s: dedup_key: %v", r.Name, err)
+			}
+		}
+
+		for j := range r.Steps {
+			if err := r.Steps[j].compile(); err != nil {
+				return nil, fmt.Errorf("rule %s step %d: %v", r.Name, j+1, err)
+			}
+		}
+		for j := range r.Actions {
+			if err := r.Actions[j].compile(); err != nil {
+				return nil, fmt.Errorf("rule %s action %d: %v", r.Name, j+1, err)
+			}
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+func (s *RuleStep) compile() error {
+	set := 0
+	for _, v := range []string{s.Shell, s.Kubectl, s.PromQL} {
+		if v != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return fmt.Errorf("exactly one of shell, kubectl or promql must be set")
+	}
+
+	if s.Timeout <= 0 {
+		s.Timeout = defaultStepTimeout
+	}
+	if s.MaxLines <= 0 {
+		s.MaxLines = defaultStepMaxLines
+	}
+
+	tmpl, err := template.New("step").Funcs(templateFuncs).Option("missingkey=error").Parse(s.Shell + s.Kubectl + s.PromQL)
+	if err != nil {
+		return err
+	}
+	s.tmpl = tmpl
+	return nil
+}
+
+func (a *RuleAction) compile() error {
+	if a.Name == "" {
+		return fmt.Errorf("action needs a name")
+	}
+	if (a.Shell == "") == (a.Kubectl == "") {
+		return fmt.Errorf("exactly one of shell or kubectl must be set")
+	}
+
+	src := a.Shell
+	if a.Kubectl != "" {
+		src = "kubectl " + a.Kubectl
+	}
+	tmpl, err := template.New("action").Funcs(templateFuncs).Option("missingkey=error").Parse(src)
+	if err != nil {
+		return err
+	}
+	a.tmpl = tmpl
+	return nil
+}
+
+// Action adapts the file action to the one used by built-in rules
+func (a *RuleAction) Action() Action {
+	return Action{
+		Name:        a.Name,
+		Description: a.Description,
+		Command: func(alert Alert) (string, error) {
+			var cmd bytes.Buffer
+			if err := a.tmpl.Execute(&cmd, newShellAlert(alert)); err != nil {
+				return "", err
+			}
+			return cmd.String(), nil
+		},
+	}
+}
+
+// Matches reports whether the rule applies to the alert
+func (r *FileRule) Matches(alert Alert) bool {
+	if r.AlertName != "" && alert.Labels["alertname"] != r.AlertName {
+		return false
+	}
+	return matchesAll(r.matchers, alert.Labels)
+}
+
+// Rule adapts the file rule to the scheduler used for built-in rules
+func (r *FileRule) Rule() Rule {
+	rule := Rule{Name: r.Name, Run: r.Run}
+	for i := range r.Actions {
+		rule.Actions = append(rule.Actions, r.Actions[i].Action())
+	}
+	if r.Scope == "cluster" {
+		rule.Scope = PerCluster
+	}
+	if r.dedup != nil {
+		rule.DedupKey = func(alert Alert) string {
+			var key bytes.Buffer
+			if err := r.dedup.Execute(&key, alert); err != nil {
+				return alert.Key()
+			}
+			return key.String()
+		}
+	}
+	return rule
+}
+
+// Run executes every step in order through the same local/SSH path as the
+// built-in rules
+func (r *FileRule) Run(d *Diagnosis, alert Alert) {
+	for _, step := range r.Steps {
+		var rendered bytes.Buffer
+		if err := step.tmpl.Execute(&rendered, step.data(alert)); err != nil {
+			d.Printf("\n   🔍 [Diagnosis] %s\n      ❌ Failed to render step: %v\n", r.Name, err)
+			continue
+		}
+		cmdStr := rendered.String()
+
+		title := step.Name
+		if title == "" {
+			title = cmdStr
+		}
+		d.Printf("\n   🔍 [Diagnosis] %s: %s\n", r.Name, title)
+
+		ctx, cancel := context.WithTimeout(context.Background(), step.Timeout)
+		output, err := step.execute(ctx, d, cmdStr)
+		timedOut := ctx.Err() == context.DeadlineExceeded
+		cancel()
+
+		if timedOut {
+			d.Printf("      ⏳ Timed out after %s\n", step.Timeout)
+			continue
+		}
+		if err != nil {
+			d.Printf("      ❌ %v\n", err)
+		}
+		d.printTruncated(output, step.MaxLines)
+	}
+	d.Println("")
+}
+
+// data returns what the step renders against. PromQL steps are not run by a
+// shell and see the values unquoted.
+func (s *RuleStep) data(alert Alert) any {
+	if s.PromQL != "" {
+		return alert
+	}
+	return newShellAlert(alert)
+}
+
+func (s *RuleStep) execute(ctx context.Context, d *Diagnosis, cmdStr string) (string, error) {
+	switch {
+	case s.PromQL != "":
+		resp, err := d.Query(ctx, cmdStr)
+		if err != nil {
+			return "", err
+		}
+		if len(resp.Data.Result) == 0 {
+			return "(no series)", nil
+		}
+		var sb strings.Builder
+		for _, res := range resp.Data.Result {
+			value := ""
+			if len(res.Value) == 2 {
+				value = fmt.Sprint(res.Value[1])
+			}
+			fmt.Fprintf(&sb, "%s = %s\n", formatMetric(res.Metric), value)
+		}
+		return sb.String(), nil
+	case s.Kubectl != "":
+		cmdStr = "kubectl " + cmdStr
+	}
+
+	output, err := d.RunContext(ctx, cmdStr)
+	return string(output), err
+}
+
+// formatMetric renders a label set as name{k="v", ...}
+func formatMetric(metric map[string]string) string {
+	name := metric["__name__"]
+	keys := make([]string, 0, len(metric))
+	for k := range metric {
+		if k != "__name__" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%q", k, metric[k]))
+	}
+	return name + "{" + strings.Join(pairs, ", ") + "}"
+}
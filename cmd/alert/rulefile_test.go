@@ -0,0 +1,42 @@
+package alert
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestStepTemplateQuoting(t *testing.T) {
+	alert := Alert{Labels: map[string]string{"mountpoint": "/data; rm -rf / #", "instance": "$(id)`id`:9100"}}
+	tests := []struct {
+		step RuleStep
+		want string
+	}{
+		{RuleStep{Shell: `df -h {{ .Labels.mountpoint }}`}, `df -h '/data; rm -rf / #'`},
+		{RuleStep{Shell: `df -h {{ .Labels.mountpoint | quote }}`}, `df -h '/data; rm -rf / #'`},
+		{RuleStep{Shell: `echo {{ quote (printf "%s%s" (raw .Labels.mountpoint) "/x") }}`}, `echo '/data; rm -rf / #/x'`},
+		{RuleStep{Kubectl: `logs {{ .Labels.instance }}`}, `logs '$(id)` + "`id`" + `:9100'`},
+		{RuleStep{Shell: `{{ if eq .Labels.instance "x" }}yes{{ else }}no{{ end }}`}, `no`},
+		{RuleStep{PromQL: `up{instance="{{ .Labels.instance }}"}`}, `up{instance="$(id)` + "`id`" + `:9100"}`},
+	}
+	for _, tt := range tests {
+		if err := tt.step.compile(); err != nil {
+			t.Fatal(err)
+		}
+		var got bytes.Buffer
+		if err := tt.step.tmpl.Execute(&got, tt.step.data(alert)); err != nil {
+			t.Errorf("%s: %v", tt.want, err)
+			continue
+		}
+		if got.String() != tt.want {
+			t.Errorf("rendered %q, want %q", got.String(), tt.want)
+		}
+	}
+
+	a := RuleAction{Name: "x", Shell: `umount {{ .Labels.mountpoint }}`}
+	if err := a.compile(); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := a.Action().Command(alert); got != `umount '/data; rm -rf / #'` {
+		t.Errorf("action rendered %q", got)
+	}
+}
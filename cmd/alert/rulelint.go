@@ -0,0 +1,315 @@
+package alert
+
+import (
+	"flag"
+	"fmt"
+	"god/cmd/config"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// lintProblem is one finding of `god alert rules lint`
+type lintProblem struct {
+	file string
+	line int
+	rule string
+	msg  string
+}
+
+// runRules routes `god alert rules <lint|test>`
+func runRules(args []string) {
+	if len(args) < 1 {
+		fmt.Println("Usage: god alert rules <lint|test> <paths...> [flags]")
+		os.Exit(1)
+	}
+	switch args[0] {
+	case "lint":
+		runRulesLint(args[1:])
+	case "test":
+		runRulesTest(args[1:])
+	default:
+		fmt.Printf("Unknown rules command: %s (expected lint or test)\n", args[0])
+		os.Exit(1)
+	}
+}
+
+// rulesLintFlags holds the flags of god alert rules lint
+type rulesLintFlags struct {
+	*flag.FlagSet
+	requireLabels      *string
+	requireAnnotations *string
+}
+
+func newRulesLintFlags() *rulesLintFlags {
+	f := &rulesLintFlags{FlagSet: flag.NewFlagSet("rules lint", flag.ExitOnError)}
+	f.requireLabels = f.String("require-label", "severity", "Comma-separated labels every alert must have")
+	f.requireAnnotations = f.String("require-annotation", "summary,runbook_url", "Comma-separated annotations every alert must have")
+	return f
+}
+
+func runRulesLint(args []string) {
+	f := newRulesLintFlags()
+	requireLabels, requireAnnotations := f.requireLabels, f.requireAnnotations
+	config.Apply(f.FlagSet, "alert")
+	paths := parseInterleaved(f.FlagSet, args)
+
+	if len(paths) == 0 {
+		fmt.Println("❌ Error: Missing path, e.g. god alert rules lint ./monitoring/rules")
+		os.Exit(1)
+	}
+
+	files, err := ruleFilePaths(paths)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "god-rules-")
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	l := &ruleLinter{
+		requireLabels:      splitList(*requireLabels),
+		requireAnnotations: splitList(*requireAnnotations),
+		seen:               make(map[string]string),
+	}
+	for _, f := range files {
+		l.lintFile(f, tmpDir)
+	}
+	if err := l.promtoolCheck(); err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	sort.SliceStable(l.problems, func(i, j int) bool {
+		if l.problems[i].file != l.problems[j].file {
+			return l.problems[i].file < l.problems[j].file
+		}
+		return l.problems[i].line < l.problems[j].line
+	})
+	for _, p := range l.problems {
+		where := p.file
+		if p.line > 0 {
+			where = fmt.Sprintf("%s:%d", p.file, p.line)
+		}
+		if p.rule != "" {
+			where += " " + p.rule
+		}
+		fmt.Printf("❌ %s: %s\n", where, p.msg)
+	}
+
+	summary := fmt.Sprintf("%d rules in %d groups from %d files", l.rules, l.groups, l.files)
+	if len(l.problems) > 0 {
+		fmt.Printf("\n📋 %s: %d problems\n", summary, len(l.problems))
+		os.Exit(1)
+	}
+	if l.groups == 0 {
+		fmt.Printf("⚠️  No rule groups found in %s\n", strings.Join(paths, ", "))
+		os.Exit(1)
+	}
+	fmt.Printf("✅ %s, no problems\n", summary)
+}
+
+// ruleFilePaths expands directories to the YAML files below them
+func ruleFilePaths(paths []string) ([]string, error) {
+	var files []string
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, p)
+			continue
+		}
+		err = filepath.WalkDir(p, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() && path != p && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			if ext := filepath.Ext(path); !d.IsDir() && (ext == ".yaml" || ext == ".yml") {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+type ruleLinter struct {
+	requireLabels      []string
+	requireAnnotations []string
+
+	// seen maps an alert or record identity to where it was first defined
+	seen     map[string]string
+	sources  []promRuleSource
+	problems []lintProblem
+
+	files, groups, rules int
+}
+
+func (l *ruleLinter) report(file string, line int, rule, format string, args ...interface{}) {
+	l.problems = append(l.problems, lintProblem{file: file, line: line, rule: rule, msg: fmt.Sprintf(format, args...)})
+}
+
+// lintFile checks what promtool doesn't know about: the labels and
+// annotations this team requires, and duplicates across files
+func (l *ruleLinter) lintFile(path, tmpDir string) {
+	sources, err := loadPromRuleFile(path, tmpDir)
+	if err != nil {
+		l.report(path, 0, "", "%v", err)
+		return
+	}
+	if len(sources) == 0 {
+		return
+	}
+	l.files++
+	l.sources = append(l.sources, sources...)
+
+	for _, src := range sources {
+		for _, g := range src.groups {
+			l.groups++
+			for i := range g.Rules {
+				l.rules++
+				l.lintRule(path, &g.Rules[i])
+			}
+		}
+	}
+}
+
+func (l *ruleLinter) lintRule(path string, r *promRule) {
+	name := r.Name()
+	report := func(format string, args ...interface{}) {
+		l.report(path, r.line, name, format, args...)
+	}
+
+	if r.Record != "" {
+		l.checkDuplicate(path, r, "record")
+		return
+	}
+	if r.Alert == "" {
+		return
+	}
+
+	for _, k := range l.requireLabels {
+		if strings.TrimSpace(r.Labels[k]) == "" {
+			report("missing label %s", k)
+		}
+	}
+	for _, k := range l.requireAnnotations {
+		if strings.TrimSpace(r.Annotations[k]) == "" {
+			report("missing annotation %s", k)
+		}
+	}
+	l.checkDuplicate(path, r, "alert")
+}
+
+// checkDuplicate flags rules with the same name and static labels. The same
+// alert name with different severities (a warning and a critical threshold)
+// is fine; two identical definitions fire indistinguishable alerts.
+func (l *ruleLinter) checkDuplicate(path string, r *promRule, kind string) {
+	labels := map[string]string{"__name__": r.Name()}
+	for k, v := range r.Labels {
+		labels[k] = v
+	}
+	key := kind + " " + formatMetric(labels)
+	where := fmt.Sprintf("%s:%d", path, r.line)
+	if first, ok := l.seen[key]; ok {
+		l.report(path, r.line, r.Name(), "duplicate %s with the same labels (also at %s)", kind, first)
+		return
+	}
+	l.seen[key] = where
+}
+
+// --- promtool ---
+
+var (
+	// promtoolRuleErrRe matches `5:15: group "g", rule 1, "A": could not ...`
+	// and template errors, which come without a position
+	promtoolRuleErrRe = regexp.MustCompile(`^(?:\d+:\d+: )?group "(.*?)", rule (\d+), "(.*?)": (.*)$`)
+	// promtoolLineErrRe matches `5:15: ...` and the `line 6: ...` of YAML errors
+	promtoolLineErrRe = regexp.MustCompile(`^(?:line )?(\d+)(?::\d+)?: (.*)$`)
+)
+
+// promtoolCheck runs `promtool check rules` on every source at once and
+// adds its findings: PromQL syntax, durations, templates and unknown fields
+func (l *ruleLinter) promtoolCheck() error {
+	if len(l.sources) == 0 {
+		return nil
+	}
+	args := []string{"check", "rules", "--lint=none"}
+	byPath := make(map[string]promRuleSource, len(l.sources))
+	for _, src := range l.sources {
+		args = append(args, src.path)
+		byPath[src.path] = src
+	}
+
+	output, err := runPromtool(args...)
+	if err == nil {
+		return nil
+	}
+	if output == "" {
+		return err
+	}
+
+	found := len(l.problems)
+	var src promRuleSource
+	for _, line := range strings.Split(output, "\n") {
+		if path, ok := strings.CutPrefix(line, "Checking "); ok {
+			src = byPath[path]
+			continue
+		}
+		msg := strings.TrimSpace(strings.TrimPrefix(line, src.path+": "))
+		if src.path == "" || msg == "" || msg == "FAILED:" || strings.HasPrefix(msg, "SUCCESS") || strings.HasSuffix(msg, ":") {
+			continue
+		}
+		l.promtoolProblem(src, msg)
+	}
+	if len(l.problems) == found {
+		return fmt.Errorf("promtool check rules failed:\n%s", unextractPaths(output, l.sources))
+	}
+	return nil
+}
+
+// promtoolProblem reports a promtool error at the rule it names, or at the
+// line it names when promtool read the file itself
+func (l *ruleLinter) promtoolProblem(src promRuleSource, msg string) {
+	if m := promtoolRuleErrRe.FindStringSubmatch(msg); m != nil {
+		n, _ := strconv.Atoi(m[2])
+		for _, g := range src.groups {
+			if g.Name == m[1] && n >= 1 && n <= len(g.Rules) {
+				l.report(src.file, g.Rules[n-1].line, m[3], "%s", m[4])
+				return
+			}
+		}
+	}
+	line := 0
+	if m := promtoolLineErrRe.FindStringSubmatch(msg); m != nil && src.path == src.file {
+		line, _ = strconv.Atoi(m[1])
+		msg = m[2]
+	}
+	l.report(src.file, line, "", "%s", unextractPaths(msg, l.sources))
+}
@@ -1,101 +1,267 @@
 package alert
 
 import (
+	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
 	"text/tabwriter"
+	"time"
 )
 
-// RuleFunc defines the function signature for a diagnostic check
-type RuleFunc func(alert Alert, server string)
-
-// DiagnosticRules maps an "AlertName" to a specific function
-var DiagnosticRules = map[string]RuleFunc{
-	"VeleroUnsuccessfulBackup": checkVeleroBackup,
-	"ArgoCdAppUnhealthy":       checkArgoUnhealthy,
+// DiagnosticRules maps an "AlertName" to a specific rule
+var DiagnosticRules = map[string]Rule{
+	"VeleroUnsuccessfulBackup":      {Name: "VeleroUnsuccessfulBackup", Run: checkVeleroBackup, DedupKey: labelKey("schedule"), Actions: []Action{veleroBackupAction}},
+	"ArgoCdAppUnhealthy":            {Name: "ArgoCdAppUnhealthy", Run: checkArgoUnhealthy, DedupKey: labelKey("name"), Actions: []Action{argoSyncAction}},
+	"KubePodCrashLooping":           {Name: "KubePodCrashLooping", Run: checkPodCrashLooping, DedupKey: labelKey("namespace", "pod", "container"), Actions: []Action{deletePodAction}},
+	"KubePodNotReady":               {Name: "KubePodNotReady", Run: checkPodNotReady, DedupKey: labelKey("namespace", "pod"), Actions: []Action{deletePodAction}},
+	"KubePersistentVolumeFillingUp": {Name: "KubePersistentVolumeFillingUp", Run: checkPVCFillingUp, DedupKey: labelKey("namespace", "persistentvolumeclaim")},
+	"KubeNodeNotReady":              {Name: "KubeNodeNotReady", Run: checkNodeNotReady, DedupKey: labelKey("node"), Actions: []Action{cordonNodeAction}},
+	"KubeJobFailed":                 {Name: "KubeJobFailed", Run: checkJobFailed, DedupKey: labelKey("namespace", "job_name")},
+	"TargetDown":                    {Name: "TargetDown", Run: checkTargetDown, DedupKey: labelKey("namespace", "job")},
 }
 
 // --- Helper: Command Execution ---
 
+// contextShim makes every kubectl, velero and helm in a local shell command
+// use the target's context, so commands never depend on (or change) the
+// current context
+const contextShim = `kubectl() { command kubectl --context "$GOD_KUBE_CONTEXT" "$@"; }; ` +
+	`velero() { command velero --kubecontext "$GOD_KUBE_CONTEXT" "$@"; }; ` +
+	`helm() { command helm --kube-context "$GOD_KUBE_CONTEXT" "$@"; }; `
+
 // runCommand executes a shell string locally or remotely over SSH as root
-func runCommand(server, cmdStr string) ([]byte, error) {
-	if server != "" {
-		fmt.Println("      [SSH] (Touch YubiKey if it blinks...)")
+func runCommand(t Target, cmdStr string) ([]byte, error) {
+	return runCommandContext(context.Background(), t, cmdStr)
+}
+
+// runCommandContext is runCommand with a deadline or cancellation
+func runCommandContext(ctx context.Context, t Target, cmdStr string) ([]byte, error) {
+	var cmd *exec.Cmd
+	if t.IsSSH() {
+		// Prompts go to stderr so they never mix with JSON output
+		fmt.Fprintln(os.Stderr, "      [SSH] (Touch YubiKey if it blinks...)")
 		// Use sudo -i to ensure root's PATH and kubeconfig are fully loaded
 		remoteCmd := fmt.Sprintf("sudo -i %s", cmdStr)
-		// -t forces PTY so PAM can request the YubiKey
-		return exec.Command("ssh", "-t", server, remoteCmd).CombinedOutput()
+		cmd = sshCommand(ctx, t, remoteCmd)
+	} else if t.Context != "" {
+		cmd = exec.CommandContext(ctx, "sh", "-c", contextShim+cmdStr)
+		cmd.Env = append(os.Environ(), "GOD_KUBE_CONTEXT="+t.Context)
+	} else {
+		// Run locally via shell
+		cmd = exec.CommandContext(ctx, "sh", "-c", cmdStr)
 	}
-	// Run locally via shell
-	return exec.Command("sh", "-c", cmdStr).CombinedOutput()
+	// Don't wait for grandchildren still holding the output pipe after a timeout
+	cmd.WaitDelay = time.Second
+	return audited(t, cmd, cmdStr, (*exec.Cmd).CombinedOutput)
 }
 
-// --- Prometheus Response Structs ---
+// prometheusGet fetches a Prometheus HTTP API path (e.g. "/api/v1/targets")
+// through the kube API proxy, or directly by URL, and returns the JSON body
+func prometheusGet(ctx context.Context, t Target, path string) ([]byte, error) {
+	prom := prometheusEndpoint(t)
+	if prom.Direct() {
+		body, err := directGet(ctx, t, prom, path)
+		// A JSON error body carries Prometheus's reason; the caller decodes it
+		var se *httpStatusError
+		if errors.As(err, &se) && se.json {
+			return se.body, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to query Prometheus: %v", err)
+		}
+		return body, nil
+	}
 
-type PromResponse struct {
-	Status string `json:"status"`
-	Data   struct {
-		ResultType string `json:"resultType"`
-		Result     []struct {
-			Metric map[string]string `json:"metric"`
-			Value  []interface{}     `json:"value"`
-		} `json:"result"`
-	} `json:"data"`
+	apiPath := prom.ProxyPath(path)
+	cmdStr := fmt.Sprintf("kubectl get --raw '%s'", apiPath)
+
+	output, err := runCommandContext(ctx, t, cmdStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Prometheus: %v", err)
+	}
+	return extractJSON(output, '{', '}'), nil
 }
 
-// --- Rule Implementations ---
+// alertmanagerGet fetches an Alertmanager API v2 path (e.g. "/api/v2/status")
+// through the kube API proxy, or directly by URL, and returns the JSON body
+func alertmanagerGet(ctx context.Context, t Target, path string) ([]byte, error) {
+	am := alertmanagerEndpoint(t)
+	if am.Direct() {
+		body, err := directGet(ctx, t, am, path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query Alertmanager: %v", err)
+		}
+		return body, nil
+	}
 
-func checkArgoUnhealthy(alert Alert, server string) {
-	fmt.Println("\n   🔍 [Diagnosis] Querying Prometheus for unhealthy ArgoCD apps...")
+	cmdStr := fmt.Sprintf("kubectl get --raw '%s'", am.ProxyPath(path))
+	output, err := runCommandContext(ctx, t, cmdStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query Alertmanager: %v\n      Raw Output: %s", err, string(output))
+	}
 
-	// Smart Namespace Override for Prometheus queries
-	promNs := "monitoring"
-	if server != "" {
-		promNs = "monitoring-linuxaid"
+	// The body is an array (alerts, silences) or an object (status)
+	switch i := strings.IndexAny(string(output), "[{"); {
+	case i < 0:
+		return nil, fmt.Errorf("invalid response from Alertmanager: %s", string(output))
+	case output[i] == '[':
+		return extractJSON(output, '[', ']'), nil
 	}
+	return extractJSON(output, '{', '}'), nil
+}
 
-	promQuery := `argocd_app_info{health_status!="Healthy"}`
-	apiPath := fmt.Sprintf("/api/v1/namespaces/%s/services/prometheus-operated:9090/proxy/api/v1/query?query=%s", promNs, url.QueryEscape(promQuery))
+// alertmanagerPost sends a JSON body to an Alertmanager API v2 path (e.g.
+// "/api/v2/silences") and returns the JSON response
+func alertmanagerPost(ctx context.Context, t Target, path string, body []byte) ([]byte, error) {
+	am := alertmanagerEndpoint(t)
+	if am.Direct() {
+		resp, err := directPost(ctx, t, am, path, body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to post to Alertmanager: %v", err)
+		}
+		return resp, nil
+	}
 
-	cmdStr := fmt.Sprintf("kubectl get --raw '%s'", apiPath)
+	// The body travels base64-encoded so it survives SSH and sudo quoting;
+	// the pipeline runs in its own shell so sudo covers all of it
+	pipeline := fmt.Sprintf("echo %s | base64 -d | kubectl create --raw %s -f -",
+		base64.StdEncoding.EncodeToString(body), shellQuote(am.ProxyPath(path)))
+	cmdStr := pipeline
+	if t.IsSSH() {
+		cmdStr = "sh -c " + shellQuote(pipeline)
+	}
+	output, err := runCommandContext(ctx, t, cmdStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to post to Alertmanager: %v\n      Raw Output: %s", err, string(output))
+	}
+	return extractJSON(output, '{', '}'), nil
+}
+
+// queryPrometheus runs an instant PromQL query through the kube API proxy
+func queryPrometheus(ctx context.Context, t Target, promQuery string) (*PromResponse, error) {
+	return decodePromResponse(prometheusGet(ctx, t, "/api/v1/query?query="+url.QueryEscape(promQuery)))
+}
 
-	output, err := runCommand(server, cmdStr)
+// queryPrometheusRange runs a PromQL range query returning a matrix
+func queryPrometheusRange(ctx context.Context, t Target, promQuery string, start, end time.Time, step time.Duration) (*PromResponse, error) {
+	params := url.Values{}
+	params.Set("query", promQuery)
+	params.Set("start", strconv.FormatInt(start.Unix(), 10))
+	params.Set("end", strconv.FormatInt(end.Unix(), 10))
+	params.Set("step", strconv.FormatFloat(step.Seconds(), 'f', -1, 64))
+	return decodePromResponse(prometheusGet(ctx, t, "/api/v1/query_range?"+params.Encode()))
+}
+
+func decodePromResponse(body []byte, err error) (*PromResponse, error) {
 	if err != nil {
-		fmt.Printf("      ❌ Failed to query Prometheus: %v\n", err)
-		return
+		return nil, err
 	}
 
 	var resp PromResponse
-	if err := json.Unmarshal(output, &resp); err != nil {
-		fmt.Printf("      ❌ Failed to parse Prometheus JSON: %v\n", err)
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("failed to parse Prometheus JSON: %v", err)
+	}
+	if resp.Status == "error" {
+		return nil, fmt.Errorf("prometheus %s error: %s", resp.ErrorType, resp.Error)
+	}
+	return &resp, nil
+}
+
+// --- Prometheus Response Structs ---
+
+type PromResponse struct {
+	Status    string `json:"status"`
+	ErrorType string `json:"errorType,omitempty"`
+	Error     string `json:"error,omitempty"`
+	Data      struct {
+		ResultType string     `json:"resultType"`
+		Result     PromResult `json:"result"`
+	} `json:"data"`
+}
+
+// PromSeries is one element of a vector ("value") or matrix ("values") result
+type PromSeries struct {
+	Metric map[string]string `json:"metric"`
+	Value  []interface{}     `json:"value,omitempty"`
+	Values [][]interface{}   `json:"values,omitempty"`
+}
+
+// PromResult holds the series of a query result. Scalar and string results,
+// which Prometheus encodes as a bare [time, "value"] pair, become a single
+// series without labels.
+type PromResult []PromSeries
+
+func (r *PromResult) UnmarshalJSON(b []byte) error {
+	trimmed := strings.TrimSpace(string(b))
+	if strings.HasPrefix(trimmed, "[") {
+		rest := strings.TrimSpace(trimmed[1:])
+		if !strings.HasPrefix(rest, "{") && !strings.HasPrefix(rest, "]") {
+			var pair []interface{}
+			if err := json.Unmarshal(b, &pair); err != nil {
+				return err
+			}
+			*r = PromResult{{Metric: map[string]string{}, Value: pair}}
+			return nil
+		}
+	}
+
+	var series []PromSeries
+	if err := json.Unmarshal(b, &series); err != nil {
+		return err
+	}
+	*r = series
+	return nil
+}
+
+// --- Rule Implementations ---
+
+func checkArgoUnhealthy(d *Diagnosis, alert Alert) {
+	d.Println("\n   🔍 [Diagnosis] Querying Prometheus for unhealthy ArgoCD apps...")
+
+	// Narrow the query to the app this alert is about, when it says so
+	promQuery := `argocd_app_info{health_status!="Healthy"}`
+	if app := alert.Labels["name"]; app != "" {
+		promQuery = fmt.Sprintf(`argocd_app_info{health_status!="Healthy", name=%q}`, app)
+	}
+	resp, err := d.Query(context.Background(), promQuery)
+	if err != nil {
+		d.Printf("      ❌ %v\n", err)
 		return
 	}
 
 	if len(resp.Data.Result) == 0 {
-		fmt.Println("      ✅ Prometheus returned no unhealthy apps")
+		d.Println("      ✅ Prometheus returned no unhealthy apps")
 		return
 	}
 
-	w := tabwriter.NewWriter(os.Stdout, 0, 0, 3, ' ', 0)
+	w := tabwriter.NewWriter(d.Writer(), 0, 0, 3, ' ', 0)
 	for _, res := range resp.Data.Result {
 		appName := strings.TrimSpace(res.Metric["name"])
 		fmt.Fprintf(w, "      ⚠️  App: %s\t| Health: %s\t| Sync: %s\t| Ns: %s\n",
 			appName, res.Metric["health_status"], res.Metric["sync_status"], res.Metric["dest_namespace"])
 	}
 	w.Flush()
-	fmt.Println("")
+	d.Println("")
 }
 
-func checkVeleroBackup(alert Alert, server string) {
-	fmt.Println("\n   🔍 [Diagnosis] Running: velero get backup (showing top 5)")
+func checkVeleroBackup(d *Diagnosis, alert Alert) {
+	// Only look at the failing schedule's backups when the alert names one
+	getCmdStr := "velero get backup"
+	if schedule := alert.Labels["schedule"]; schedule != "" {
+		getCmdStr = fmt.Sprintf("velero get backup --selector velero.io/schedule-name=%s", shellQuote(schedule))
+	}
+	d.Printf("\n   🔍 [Diagnosis] Running: %s (showing top 5)\n", getCmdStr)
 
-	output, err := runCommand(server, "velero get backup")
+	output, err := d.Run(getCmdStr)
 	if err != nil {
-		fmt.Printf("      ❌ Failed to run velero: %v\n", err)
+		d.Printf("      ❌ Failed to run velero: %v\n", err)
 		return
 	}
 
@@ -107,28 +273,28 @@ func checkVeleroBackup(alert Alert, server string) {
 		limit = len(lines)
 	}
 	for i := 0; i < limit; i++ {
-		fmt.Printf("      %s\n", lines[i])
+		d.Printf("      %s\n", lines[i])
 	}
 
 	if len(lines) > 1 {
 		fields := strings.Fields(lines[1])
 		if len(fields) > 0 {
 			latestBackup := fields[0]
-			fmt.Printf("\n   🔍 [Diagnosis] Describing latest backup: %s\n", latestBackup)
+			d.Printf("\n   🔍 [Diagnosis] Describing latest backup: %s\n", latestBackup)
 
 			descCmdStr := fmt.Sprintf("velero describe backup %s --details", latestBackup)
-			descOutput, err := runCommand(server, descCmdStr)
+			descOutput, err := d.Run(descCmdStr)
 			if err != nil {
-				fmt.Printf("      ❌ Failed to describe backup: %v\n", err)
+				d.Printf("      ❌ Failed to describe backup: %v\n", err)
 				return
 			}
 
 			cleanDescOutput := strings.ReplaceAll(string(descOutput), "\r", "")
 			descLines := strings.Split(cleanDescOutput, "\n")
 			for _, line := range descLines {
-				fmt.Printf("      %s\n", line)
+				d.Printf("      %s\n", line)
 			}
 		}
 	}
-	fmt.Println("")
+	d.Println("")
 }
@@ -0,0 +1,203 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// kubeDiagMaxLines caps the output of a single diagnostic command
+const kubeDiagMaxLines = 30
+
+// --- Rule Implementations: Kubernetes ---
+
+func checkPodCrashLooping(d *Diagnosis, alert Alert) {
+	labels, ok := d.requireLabels(alert, "namespace", "pod")
+	if !ok {
+		return
+	}
+	ns, pod := labels[0], labels[1]
+
+	d.diagnose(fmt.Sprintf("Describing pod %s/%s", ns, pod),
+		fmt.Sprintf("kubectl describe pod -n %s %s", shellQuote(ns), shellQuote(pod)), kubeDiagMaxLines)
+
+	logsCmd := fmt.Sprintf("kubectl logs -n %s %s --previous --tail=%d", shellQuote(ns), shellQuote(pod), kubeDiagMaxLines)
+	if container := alert.Labels["container"]; container != "" {
+		logsCmd += " -c " + shellQuote(container)
+	}
+	d.diagnose("Previous container logs (tail)", logsCmd, kubeDiagMaxLines)
+	d.Println("")
+}
+
+func checkPodNotReady(d *Diagnosis, alert Alert) {
+	labels, ok := d.requireLabels(alert, "namespace", "pod")
+	if !ok {
+		return
+	}
+	ns, pod := labels[0], labels[1]
+
+	d.diagnose(fmt.Sprintf("Describing pod %s/%s", ns, pod),
+		fmt.Sprintf("kubectl describe pod -n %s %s", shellQuote(ns), shellQuote(pod)), kubeDiagMaxLines)
+	d.diagnose("Recent events",
+		fmt.Sprintf("kubectl get events -n %s --field-selector involvedObject.name=%s --sort-by=.lastTimestamp", shellQuote(ns), shellQuote(pod)), kubeDiagMaxLines)
+	d.Println("")
+}
+
+func checkPVCFillingUp(d *Diagnosis, alert Alert) {
+	labels, ok := d.requireLabels(alert, "namespace", "persistentvolumeclaim")
+	if !ok {
+		return
+	}
+	ns, pvc := labels[0], labels[1]
+
+	d.Printf("\n   🔍 [Diagnosis] Querying Prometheus for usage of PVC %s/%s\n", ns, pvc)
+	selector := fmt.Sprintf(`{namespace=%q, persistentvolumeclaim=%q}`, ns, pvc)
+
+	values := make(map[string]float64)
+	for _, metric := range []string{"used", "capacity", "available"} {
+		resp, err := d.Query(context.Background(), "kubelet_volume_stats_"+metric+"_bytes"+selector)
+		if err != nil {
+			d.Printf("      ❌ %v\n", err)
+			return
+		}
+		if len(resp.Data.Result) == 0 || len(resp.Data.Result[0].Value) != 2 {
+			continue
+		}
+		if v, err := strconv.ParseFloat(fmt.Sprint(resp.Data.Result[0].Value[1]), 64); err == nil {
+			values[metric] = v
+		}
+	}
+
+	if capacity, ok := values["capacity"]; ok && capacity > 0 {
+		d.Printf("      💾 Used: %s / %s (%.1f%%), available: %s\n",
+			formatBytes(values["used"]), formatBytes(capacity), values["used"]/capacity*100, formatBytes(values["available"]))
+	} else {
+		d.Println("      ⚠️  No kubelet volume stats found for this PVC")
+	}
+
+	d.diagnose("PVC status",
+		fmt.Sprintf("kubectl get pvc -n %s %s -o wide", shellQuote(ns), shellQuote(pvc)), kubeDiagMaxLines)
+	d.Println("")
+}
+
+func checkNodeNotReady(d *Diagnosis, alert Alert) {
+	labels, ok := d.requireLabels(alert, "node")
+	if !ok {
+		return
+	}
+	node := labels[0]
+
+	jsonPath := `{range .status.conditions[*]}{.type}{"\t"}{.status}{"\t"}{.reason}{"\t"}{.lastTransitionTime}{"\t"}{.message}{"\n"}{end}`
+	d.diagnose(fmt.Sprintf("Conditions of node %s", node),
+		fmt.Sprintf("kubectl get node %s -o jsonpath=%s", shellQuote(node), shellQuote(jsonPath)), kubeDiagMaxLines)
+	d.diagnose("Recent node events",
+		fmt.Sprintf("kubectl get events -A --field-selector involvedObject.kind=Node,involvedObject.name=%s --sort-by=.lastTimestamp", shellQuote(node)), kubeDiagMaxLines)
+	d.Println("")
+}
+
+func checkJobFailed(d *Diagnosis, alert Alert) {
+	labels, ok := d.requireLabels(alert, "namespace", "job_name")
+	if !ok {
+		return
+	}
+	ns, job := labels[0], labels[1]
+
+	selector := "job-name=" + job
+	d.diagnose(fmt.Sprintf("Pods of job %s/%s", ns, job),
+		fmt.Sprintf("kubectl get pods -n %s -l %s -o wide", shellQuote(ns), shellQuote(selector)), kubeDiagMaxLines)
+
+	d.Printf("\n   🔍 [Diagnosis] Finding failed pods of job %s\n", job)
+	output, err := d.Run(fmt.Sprintf("kubectl get pods -n %s -l %s --field-selector=status.phase=Failed -o name",
+		shellQuote(ns), shellQuote(selector)))
+	if err != nil {
+		d.Printf("      ❌ Failed to list pods: %v\n", err)
+		return
+	}
+
+	var failed []string
+	for _, line := range strings.Split(strings.ReplaceAll(string(output), "\r", ""), "\n") {
+		if strings.HasPrefix(line, "pod/") {
+			failed = append(failed, line)
+		}
+	}
+	if len(failed) == 0 {
+		d.Println("      ✅ No failed pods left (they may have been garbage collected)")
+		return
+	}
+
+	// The last failed pod is usually the most relevant attempt
+	latest := failed[len(failed)-1]
+	d.diagnose(fmt.Sprintf("Logs of %s (tail)", latest),
+		fmt.Sprintf("kubectl logs -n %s %s --all-containers --tail=%d", shellQuote(ns), shellQuote(latest), kubeDiagMaxLines), kubeDiagMaxLines)
+	d.Println("")
+}
+
+// promTargetsResponse is the subset of /api/v1/targets used by checkTargetDown
+type promTargetsResponse struct {
+	Data struct {
+		ActiveTargets []struct {
+			Labels     map[string]string `json:"labels"`
+			ScrapeURL  string            `json:"scrapeUrl"`
+			Health     string            `json:"health"`
+			LastError  string            `json:"lastError"`
+			LastScrape string            `json:"lastScrape"`
+		} `json:"activeTargets"`
+	} `json:"data"`
+}
+
+func checkTargetDown(d *Diagnosis, alert Alert) {
+	labels, ok := d.requireLabels(alert, "job")
+	if !ok {
+		return
+	}
+	job := labels[0]
+
+	d.Printf("\n   🔍 [Diagnosis] Querying Prometheus for unhealthy scrape targets of job %s\n", job)
+	body, err := d.PrometheusGet(context.Background(), "/api/v1/targets?state=active")
+	if err != nil {
+		d.Printf("      ❌ %v\n", err)
+		return
+	}
+
+	var resp promTargetsResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		d.Printf("      ❌ Failed to parse Prometheus JSON: %v\n", err)
+		return
+	}
+
+	ns := alert.Labels["namespace"]
+	w := tabwriter.NewWriter(d.Writer(), 0, 0, 3, ' ', 0)
+	down := 0
+	for _, t := range resp.Data.ActiveTargets {
+		if t.Labels["job"] != job || t.Health == "up" {
+			continue
+		}
+		if ns != "" && t.Labels["namespace"] != "" && t.Labels["namespace"] != ns {
+			continue
+		}
+		down++
+		fmt.Fprintf(w, "      ⚠️  %s\t| %s\t| %s\n", t.ScrapeURL, t.Health, t.LastError)
+	}
+	w.Flush()
+
+	if down == 0 {
+		d.Println("      ✅ Prometheus reports no unhealthy targets for this job right now")
+	}
+	d.Println("")
+}
+
+// --- Helpers ---
+
+// formatBytes renders a byte count with a binary unit suffix
+func formatBytes(b float64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB"}
+	i := 0
+	for b >= 1024 && i < len(units)-1 {
+		b /= 1024
+		i++
+	}
+	return fmt.Sprintf("%.1f %s", b, units[i])
+}
@@ -0,0 +1,167 @@
+package alert
+
+import (
+	"flag"
+	"fmt"
+	"god/cmd/config"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// rulesTestFlags holds the flags of god alert rules test
+type rulesTestFlags struct {
+	*flag.FlagSet
+	run *string
+}
+
+func newRulesTestFlags() *rulesTestFlags {
+	f := &rulesTestFlags{FlagSet: flag.NewFlagSet("rules test", flag.ExitOnError)}
+	f.run = f.String("run", "", "Only run test groups whose name matches this regular expression")
+	return f
+}
+
+// runRulesTest runs promtool unit test files, e.g.
+//
+//	rule_files: [alerts.yaml]
+//	evaluation_interval: 1m
+//	tests:
+//	  - interval: 1m
+//	    input_series:
+//	      - series: 'up{job="node", instance="web-01"}'
+//	        values: '1 1 0x10'
+//	    alert_rule_test:
+//	      - eval_time: 10m
+//	        alertname: InstanceDown
+//	        exp_alerts:
+//	          - exp_labels: {severity: critical, job: node, instance: web-01}
+//	            exp_annotations: {summary: "web-01 is down"}
+//
+// through `promtool test rules`. rule_files may also be PrometheusRule
+// resources.
+func runRulesTest(args []string) {
+	f := newRulesTestFlags()
+	run := f.run
+	config.Apply(f.FlagSet, "alert")
+	paths := parseInterleaved(f.FlagSet, args)
+
+	if len(paths) == 0 {
+		fmt.Println("❌ Error: Missing test file, e.g. god alert rules test ./monitoring/tests/*.yaml")
+		os.Exit(1)
+	}
+
+	failed := 0
+	for _, path := range paths {
+		ok, err := runTestFile(path, *run)
+		if err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+			os.Exit(1)
+		}
+		if !ok {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		fmt.Printf("\n❌ %d of %d test files failed\n", failed, len(paths))
+		os.Exit(1)
+	}
+	fmt.Printf("\n✅ %d test files passed\n", len(paths))
+}
+
+// runTestFile runs one test file and prints promtool's results. The error
+// is only set when promtool could not be run at all.
+func runTestFile(path, run string) (bool, error) {
+	fmt.Printf("🧪 %s\n", path)
+	fail := func(format string, args ...interface{}) (bool, error) {
+		fmt.Printf("   ❌ "+format+"\n", args...)
+		return false, nil
+	}
+
+	tmpDir, err := os.MkdirTemp("", "god-rules-")
+	if err != nil {
+		return false, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	testPath, sources, err := prepareTestFile(path, tmpDir)
+	if err != nil {
+		return fail("%v", err)
+	}
+
+	args := []string{"test", "rules"}
+	if run != "" {
+		args = append(args, "--run="+run)
+	}
+	output, err := runPromtool(append(args, testPath)...)
+	if output == "" && err != nil {
+		return false, err
+	}
+
+	output = strings.ReplaceAll(unextractPaths(output, sources), testPath, path)
+	for _, line := range strings.Split(strings.TrimRight(output, "\n"), "\n") {
+		if strings.HasPrefix(line, "Unit Testing:") {
+			continue
+		}
+		fmt.Printf(" %s\n", line)
+	}
+	return err == nil, nil
+}
+
+// prepareTestFile copies a test file to tmpDir with its rule_files resolved
+// to absolute paths, PrometheusRule resources replaced by their extracted
+// groups
+func prepareTestFile(path, tmpDir string) (string, []promRuleSource, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return "", nil, err
+	}
+	if len(doc.Content) == 0 {
+		return "", nil, fmt.Errorf("empty test file")
+	}
+
+	var sources []promRuleSource
+	if ruleFiles := yamlValue(doc.Content[0], "rule_files"); ruleFiles != nil && ruleFiles.Kind == yaml.SequenceNode {
+		var resolved []*yaml.Node
+		for _, n := range ruleFiles.Content {
+			pattern := n.Value
+			if !filepath.IsAbs(pattern) {
+				pattern = filepath.Join(filepath.Dir(path), pattern)
+			}
+			pattern, _ = filepath.Abs(pattern)
+			matches, _ := filepath.Glob(pattern)
+			if len(matches) == 0 {
+				// promtool reports the missing file
+				matches = []string{pattern}
+			}
+			for _, m := range matches {
+				s, err := loadPromRuleFile(m, tmpDir)
+				if err != nil || len(s) == 0 {
+					resolved = append(resolved, &yaml.Node{Kind: yaml.ScalarNode, Value: m})
+					continue
+				}
+				sources = append(sources, s...)
+				for _, src := range s {
+					resolved = append(resolved, &yaml.Node{Kind: yaml.ScalarNode, Value: src.path})
+				}
+			}
+		}
+		ruleFiles.Content = resolved
+	}
+
+	out, err := yaml.Marshal(&doc)
+	if err != nil {
+		return "", nil, err
+	}
+	testPath := filepath.Join(tmpDir, filepath.Base(path))
+	if err := os.WriteFile(testPath, out, 0o600); err != nil {
+		return "", nil, err
+	}
+	return testPath, sources, nil
+}
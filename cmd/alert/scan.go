@@ -1,107 +1,144 @@
 package alert
 
 import (
-	"encoding/json"
 	"flag"
 	"fmt"
+	"god/cmd/config"
 	"os"
-	"os/exec"
 	"strings"
+	"time"
 )
 
-// TSHCluster represents the JSON output from `tsh kube ls`
-type TSHCluster struct {
-	Name string `json:"kube_cluster_name"` // <--- Fix is here
+// scanFlags holds the flags of god alert scan
+type scanFlags struct {
+	*flag.FlagSet
+	targets      *targetFlags
+	endpoints    *endpointFlags
+	groupBy      *string
+	save         *bool
+	keep         *int
+	notify       stringList
+	notifyAlways *bool
+	details      *bool
+	noColor      *bool
+}
+
+func newScanFlags() *scanFlags {
+	f := &scanFlags{FlagSet: flag.NewFlagSet("scan", flag.ExitOnError)}
+	f.targets = addTargetFlags(f.FlagSet)
+	f.endpoints = addEndpointFlags(f.FlagSet, true)
+	f.groupBy = f.String("group-by", "", "Merge results across clusters: "+strings.Join(groupByModes, "|"))
+	f.save = f.Bool("save", false, "Persist this run for `god alert diff`")
+	f.keep = f.Int("keep", defaultKeepRuns, "Number of saved runs to keep per filter when using --save")
+	f.Var(&f.notify, "notify", "Send a digest to a sink from config, or <type>=<url> (repeatable)")
+	f.notifyAlways = f.Bool("notify-always", false, "Notify even if the alert set is unchanged")
+	f.details = f.Bool("details", false, "Show summary, description, runbook and dashboard links")
+	f.noColor = addDisplayFlags(f.FlagSet)
+	return f
 }
 
 func runScan(args []string) {
-	scanCmd := flag.NewFlagSet("scan", flag.ExitOnError)
-	filter := scanCmd.String("filter", "", "Filter clusters by name (Teleport)")
-	server := scanCmd.String("server", "", "Direct SSH connection string (e.g., ubuntu@192.12.3.1)")
-	namespace := scanCmd.String("n", "monitoring", "Namespace of the Alertmanager service")
-	service := scanCmd.String("svc", "svc/alertmanager-operated", "Service name")
-	port := scanCmd.String("port", "9093", "Local port to use")
-	scanCmd.Parse(args)
-
-	if *filter == "" && *server == "" {
-		fmt.Println("❌ Error: Must provide either --filter (for Teleport) or --server (for SSH)")
+	f := newScanFlags()
+	tf, ef, groupBy, save, keep := f.targets, f.endpoints, f.groupBy, f.save, f.keep
+	notifyAlways, details, noColor := f.notifyAlways, f.details, f.noColor
+	config.Apply(f.FlagSet, "alert")
+	f.Parse(args)
+	notify := f.notify
+	configureEndpoints(ef)
+	configureDisplay(*noColor)
+
+	if tf.empty() {
+		fmt.Println("❌ Error: Must provide --filter (Teleport), --contexts (kubeconfig), --server or --group (SSH)")
 		os.Exit(1)
 	}
 
-	// --- Smart Namespace Override ---
-	actualNamespace := *namespace
-	if *server != "" && actualNamespace == "monitoring" {
-		actualNamespace = "monitoring-linuxaid"
+	if *groupBy != "" && !validGroupBy(*groupBy) {
+		fmt.Printf("❌ Error: Invalid --group-by '%s' (expected %s)\n", *groupBy, strings.Join(groupByModes, "|"))
+		os.Exit(1)
 	}
 
-	// --- BRANCH 1: Direct SSH Server ---
-	if *server != "" {
-		fmt.Printf("\n--------------------------------------------------\n")
-		fmt.Printf("🌐 Connecting via SSH to: %s\n", *server)
-
-		// Use the dynamically selected namespace here
-		alerts, err := FetchAlerts(*server, actualNamespace, *service, *port)
+	var sinks []NotifyConfig
+	if len(notify) > 0 {
+		cfg, err := loadAlertConfig()
 		if err != nil {
-			fmt.Printf("⚠️  Could not fetch alerts: %v\n", err)
-			return
+			fmt.Printf("❌ Error loading config: %v\n", err)
+			os.Exit(1)
 		}
-
-		printAlerts(alerts)
-		return
-	}
-
-	// --- BRANCH 2: Teleport Discovery ---
-	if _, err := exec.LookPath("tsh"); err != nil {
-		fmt.Println("❌ Error: 'tsh' is not installed.")
-		os.Exit(1)
-	}
-
-	fmt.Println("🔍 Fetching clusters from Teleport...")
-	cmd := exec.Command("tsh", "kube", "ls", "--format=json")
-	output, err := cmd.Output()
-	if err != nil {
-		fmt.Printf("❌ Failed to run 'tsh kube ls': %v\n", err)
-		os.Exit(1)
-	}
-
-	var allClusters []TSHCluster
-	if err := json.Unmarshal(output, &allClusters); err != nil {
-		fmt.Printf("❌ Failed to parse tsh output: %v\n", err)
-		os.Exit(1)
-	}
-
-	var targetClusters []string
-	for _, c := range allClusters {
-		if strings.Contains(c.Name, *filter) {
-			targetClusters = append(targetClusters, c.Name)
+		for _, value := range notify {
+			sink, err := parseNotifyFlag(value, cfg.Notify)
+			if err != nil {
+				fmt.Printf("❌ Error: Invalid --notify: %v\n", err)
+				os.Exit(1)
+			}
+			sinks = append(sinks, sink)
 		}
 	}
 
-	if len(targetClusters) == 0 {
-		fmt.Printf("⚠️  No clusters found matching '%s'\n", *filter)
+	targets := tf.targets()
+	if len(targets) == 0 {
 		return
 	}
+	defer releaseTargets(targets)
+	defer releaseOnSignal(targets)()
+
+	start := time.Now()
+	// Results are always collected; with --group-by they are printed once at the end
+	var results []ClusterAlerts
 
-	fmt.Printf("🚀 Found %d clusters matching '%s'. Starting scan...\n", len(targetClusters), *filter)
+	fmt.Printf("🚀 Scanning %d targets...\n", len(targets))
 
-	for _, cluster := range targetClusters {
+	for _, t := range targets {
 		fmt.Printf("\n--------------------------------------------------\n")
-		fmt.Printf("🌐 Connecting to: %s\n", cluster)
+		if t.IsSSH() && t.Name != t.Server {
+			fmt.Printf("🌐 Connecting via SSH to: %s (%s)\n", t.Name, t.Server)
+		} else if t.IsSSH() {
+			fmt.Printf("🌐 Connecting via SSH to: %s\n", t.Server)
+		} else {
+			fmt.Printf("🌐 Connecting to: %s (%s)\n", t.Name, t.Kind())
+		}
 
-		loginCmd := exec.Command("tsh", "kube", "login", cluster)
-		if out, err := loginCmd.CombinedOutput(); err != nil {
-			fmt.Printf("❌ Login failed: %v\n%s\n", err, string(out))
+		if err := t.Connect(); err != nil {
+			fmt.Printf("❌ Login failed: %v\n", err)
+			results = append(results, ClusterAlerts{Cluster: t.Name, Err: "login failed: " + err.Error()})
 			continue
 		}
 
 		fmt.Printf("🔌 Checking alerts...\n")
-		// Use the dynamically selected namespace here as well
-		alerts, err := FetchAlerts("", actualNamespace, *service, *port)
+		alerts, err := FetchAlerts(t)
 		if err != nil {
 			fmt.Printf("⚠️  Could not fetch alerts: %v\n", err)
+			results = append(results, ClusterAlerts{Cluster: t.Name, Err: err.Error()})
 			continue
 		}
 
-		printAlerts(alerts)
+		sortAlerts(alerts)
+		results = append(results, ClusterAlerts{Cluster: t.Name, Alerts: alerts})
+		if *groupBy != "" {
+			fmt.Printf("   %d active alerts\n", len(alerts))
+			continue
+		}
+		printAlerts(alerts, alertView{numbered: true, details: *details})
 	}
+
+	if *groupBy != "" {
+		printGroupedAlerts(results, *groupBy)
+	}
+
+	if *save {
+		run := newScanRun(tf.String(), start, results)
+		if err := saveScanRun(run, *keep); err != nil {
+			fmt.Printf("⚠️  Could not save scan: %v\n", err)
+		} else {
+			fmt.Printf("💾 Saved scan as %s\n", run.ID)
+		}
+	}
+
+	if len(sinks) > 0 {
+		source := tf.String()
+		sendNotifications(sinks, newDigest(source, results), *notifyAlways)
+	}
+
+	// os.Exit skips the deferred cleanup
+	releaseTargets(targets)
+	os.Exit(scanExitCode(results))
 }
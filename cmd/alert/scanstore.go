@@ -0,0 +1,178 @@
+package alert
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// scanRunVersion is bumped whenever the ScanRun file format changes
+	scanRunVersion = 1
+	// defaultKeepRuns is how many saved scans are kept by default
+	defaultKeepRuns = 50
+	scanRunIDLayout = "20060102T150405Z"
+)
+
+// ScanRun is one persisted `god alert scan --save` run
+type ScanRun struct {
+	Version  int           `json:"version"`
+	ID       string        `json:"id"`
+	Time     time.Time     `json:"time"`
+	Filter   string        `json:"filter,omitempty"`
+	Clusters []ScanCluster `json:"clusters"`
+}
+
+// ScanCluster is the outcome of one cluster in a ScanRun. Error is set when
+// its alerts could not be fetched, so a failed scan isn't mistaken for
+// "everything resolved".
+type ScanCluster struct {
+	Name   string  `json:"name"`
+	Error  string  `json:"error,omitempty"`
+	Alerts []Alert `json:"alerts"`
+}
+
+// scansDir is where ScanRun files are kept
+func scansDir() string {
+	dir := stateDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "scans")
+}
+
+// saveScanRun writes the run as <id>.json and prunes all but the newest keep
+// runs with the same filter
+func saveScanRun(run *ScanRun, keep int) error {
+	dir := scansDir()
+	if dir == "" {
+		return fmt.Errorf("cannot determine state directory")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+
+	run.Version = scanRunVersion
+	base := run.Time.UTC().Format(scanRunIDLayout)
+	run.ID = base
+	for i := 1; ; i++ {
+		if _, err := os.Stat(filepath.Join(dir, run.ID+".json")); os.IsNotExist(err) {
+			break
+		}
+		run.ID = fmt.Sprintf("%s-%d", base, i)
+	}
+
+	data, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, run.ID+".json"), data, 0o600); err != nil {
+		return err
+	}
+
+	return pruneScanRuns(dir, run.Filter, keep)
+}
+
+// pruneScanRuns deletes the oldest runs of a filter beyond keep, so frequent
+// scans of one filter don't push out the runs of another
+func pruneScanRuns(dir, filter string, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+	ids, err := listScanRunIDs(dir)
+	if err != nil {
+		return err
+	}
+	ids = scanRunsWithFilter(dir, ids, filter)
+	for len(ids) > keep {
+		if err := os.Remove(filepath.Join(dir, ids[0]+".json")); err != nil {
+			return err
+		}
+		ids = ids[1:]
+	}
+	return nil
+}
+
+// listScanRunIDs returns the saved run IDs, oldest first
+func listScanRunIDs(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var ids []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".json") {
+			ids = append(ids, strings.TrimSuffix(e.Name(), ".json"))
+		}
+	}
+	// IDs are UTC timestamps, so lexical order is chronological; runs saved
+	// within the same second get a -<n> suffix, compared as a number
+	sort.Slice(ids, func(i, j int) bool {
+		bi, ni := splitScanRunID(ids[i])
+		bj, nj := splitScanRunID(ids[j])
+		if bi != bj {
+			return bi < bj
+		}
+		return ni < nj
+	})
+	return ids, nil
+}
+
+// splitScanRunID splits an ID into its timestamp and same-second counter
+func splitScanRunID(id string) (string, int) {
+	base, suffix, ok := strings.Cut(id, "-")
+	if !ok {
+		return id, 0
+	}
+	n, err := strconv.Atoi(suffix)
+	if err != nil {
+		return id, 0
+	}
+	return base, n
+}
+
+// scanRunsWithFilter returns the runs that were saved with the given filter;
+// runs that can't be read are left out
+func scanRunsWithFilter(dir string, ids []string, filter string) []string {
+	var out []string
+	for _, id := range ids {
+		if run, err := loadScanRun(dir, id); err == nil && run.Filter == filter {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+func loadScanRun(dir, id string) (*ScanRun, error) {
+	data, err := os.ReadFile(filepath.Join(dir, id+".json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var run ScanRun
+	if err := json.Unmarshal(data, &run); err != nil {
+		return nil, fmt.Errorf("run %s: %v", id, err)
+	}
+	if run.Version > scanRunVersion {
+		return nil, fmt.Errorf("run %s was written by a newer god (format v%d)", id, run.Version)
+	}
+	return &run, nil
+}
+
+// newScanRun converts collected scan results into a ScanRun
+func newScanRun(filter string, start time.Time, results []ClusterAlerts) *ScanRun {
+	run := &ScanRun{Time: start, Filter: filter}
+	for _, res := range results {
+		run.Clusters = append(run.Clusters, ScanCluster{Name: res.Cluster, Error: res.Err, Alerts: res.Alerts})
+	}
+	return run
+}
@@ -0,0 +1,288 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"god/cmd/config"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// exporter holds the result of the latest scan and renders it as metrics.
+// Counters live across scans; everything else is replaced by each scan.
+type exporter struct {
+	mu sync.Mutex
+
+	// alerts counts firing alerts by cluster, severity and alertname
+	alerts map[[3]string]int
+	// up, durations and lastSuccess are per cluster of the latest scan
+	up          map[string]bool
+	durations   map[string]time.Duration
+	lastSuccess map[string]time.Time
+	errors      map[string]int
+
+	scans        int
+	scanDuration time.Duration
+	lastScan     time.Time
+}
+
+func newExporter() *exporter {
+	return &exporter{
+		alerts:      map[[3]string]int{},
+		up:          map[string]bool{},
+		durations:   map[string]time.Duration{},
+		lastSuccess: map[string]time.Time{},
+		errors:      map[string]int{},
+	}
+}
+
+// clusterScan is the outcome of one target in a scan
+type clusterScan struct {
+	name     string
+	alerts   []Alert
+	err      error
+	duration time.Duration
+}
+
+// serveFlags holds the flags of god alert serve
+type serveFlags struct {
+	*flag.FlagSet
+	targets   *targetFlags
+	endpoints *endpointFlags
+	listen    *string
+	interval  *time.Duration
+}
+
+func newServeFlags() *serveFlags {
+	f := &serveFlags{FlagSet: flag.NewFlagSet("serve", flag.ExitOnError)}
+	f.targets = addTargetFlags(f.FlagSet)
+	f.endpoints = addEndpointFlags(f.FlagSet, true)
+	f.listen = f.String("listen", ":9900", "Address to serve /metrics on")
+	f.interval = f.Duration("interval", time.Minute, "Time between scans")
+	return f
+}
+
+func runServe(args []string) {
+	f := newServeFlags()
+	tf, ef, listen, interval := f.targets, f.endpoints, f.listen, f.interval
+	config.Apply(f.FlagSet, "alert")
+	f.Parse(args)
+	configureEndpoints(ef)
+
+	if *interval <= 0 {
+		fmt.Println("❌ Error: --interval must be positive")
+		os.Exit(1)
+	}
+
+	exp := newExporter()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", exp.serveMetrics)
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprintln(w, `<html><head><title>god alert exporter</title></head><body><h1>god alert exporter</h1><p><a href="/metrics">Metrics</a></p></body></html>`)
+	})
+	server := &http.Server{Addr: *listen, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() { errCh <- server.ListenAndServe() }()
+	fmt.Printf("📈 Serving metrics on %s/metrics, scanning every %s\n", *listen, *interval)
+
+	// scanned is closed once the scan loop has released its targets
+	scanned := make(chan struct{})
+	go func() {
+		defer close(scanned)
+		var targets []Target
+		for {
+			current := serveTargets(tf)
+			closeDroppedSSHMasters(targets, current)
+			targets = current
+			exp.record(scanAll(targets), time.Now())
+
+			select {
+			case <-ctx.Done():
+				releaseTargets(targets)
+				return
+			case <-time.After(*interval):
+			}
+		}
+	}()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, http.ErrServerClosed) {
+			fmt.Printf("❌ Error: %v\n", err)
+			os.Exit(1)
+		}
+	case <-ctx.Done():
+		fmt.Println("\n👋 Shutting down")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+		// A scan in progress finishes first, then the kube context is restored
+		<-scanned
+	}
+}
+
+// serveTargets resolves the sources again on every scan, so clusters and
+// inventory hosts added while serving are picked up
+func serveTargets(tf *targetFlags) []Target {
+	if tf.empty() {
+		return []Target{defaultTarget()}
+	}
+	return tf.targets()
+}
+
+// scanAll fetches the alerts of every target in turn. Teleport targets switch
+// the current context, so they can't be scanned in parallel.
+func scanAll(targets []Target) []clusterScan {
+	var results []clusterScan
+	for _, t := range targets {
+		start := time.Now()
+		res := clusterScan{name: t.Name}
+		if err := t.Connect(); err != nil {
+			res.err = fmt.Errorf("login failed: %v", err)
+		} else {
+			res.alerts, res.err = FetchAlerts(t)
+		}
+		res.duration = time.Since(start)
+		if res.err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  [%s] %s: %v\n", time.Now().Format("15:04:05"), t.Name, res.err)
+		}
+		results = append(results, res)
+	}
+	restoreKubeContext()
+	return results
+}
+
+// record replaces the snapshot with a finished scan
+func (e *exporter) record(results []clusterScan, now time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.alerts = map[[3]string]int{}
+	e.up = map[string]bool{}
+	e.durations = map[string]time.Duration{}
+	var total time.Duration
+
+	for _, r := range results {
+		e.durations[r.name] = r.duration
+		total += r.duration
+		if r.err != nil {
+			e.up[r.name] = false
+			e.errors[r.name]++
+			continue
+		}
+		e.up[r.name] = true
+		e.lastSuccess[r.name] = now
+		for _, a := range r.alerts {
+			e.alerts[[3]string{r.name, a.Labels["severity"], a.Labels["alertname"]}]++
+		}
+	}
+
+	e.scans++
+	e.scanDuration = total
+	e.lastScan = now
+}
+
+// --- Exposition ---
+
+func (e *exporter) serveMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	fmt.Fprint(w, e.render())
+}
+
+// render writes the Prometheus text exposition format
+func (e *exporter) render() string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var b strings.Builder
+	header := func(name, kind, help string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+
+	header("god_alerts", "gauge", "Firing alerts by cluster, severity and alertname.")
+	keys := make([][3]string, 0, len(e.alerts))
+	for k := range e.alerts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		for n := 0; n < 3; n++ {
+			if keys[i][n] != keys[j][n] {
+				return keys[i][n] < keys[j][n]
+			}
+		}
+		return false
+	})
+	for _, k := range keys {
+		fmt.Fprintf(&b, "god_alerts{cluster=%s,severity=%s,alertname=%s} %d\n",
+			labelValue(k[0]), labelValue(k[1]), labelValue(k[2]), e.alerts[k])
+	}
+
+	clusters := sortedKeys(e.up)
+
+	header("god_scan_up", "gauge", "Whether the alerts of the cluster could be fetched in the latest scan.")
+	for _, c := range clusters {
+		up := 0
+		if e.up[c] {
+			up = 1
+		}
+		fmt.Fprintf(&b, "god_scan_up{cluster=%s} %d\n", labelValue(c), up)
+	}
+
+	header("god_scan_cluster_duration_seconds", "gauge", "Time taken to scan the cluster in the latest scan.")
+	for _, c := range clusters {
+		fmt.Fprintf(&b, "god_scan_cluster_duration_seconds{cluster=%s} %g\n", labelValue(c), e.durations[c].Seconds())
+	}
+
+	header("god_scan_errors_total", "counter", "Failed scans of the cluster since start.")
+	for _, c := range sortedKeys(e.errors) {
+		fmt.Fprintf(&b, "god_scan_errors_total{cluster=%s} %d\n", labelValue(c), e.errors[c])
+	}
+
+	header("god_scan_last_success_timestamp_seconds", "gauge", "Unix time of the last successful scan of the cluster.")
+	for _, c := range sortedKeys(e.lastSuccess) {
+		fmt.Fprintf(&b, "god_scan_last_success_timestamp_seconds{cluster=%s} %d\n", labelValue(c), e.lastSuccess[c].Unix())
+	}
+
+	header("god_scan_duration_seconds", "gauge", "Time taken by the latest scan of all clusters.")
+	fmt.Fprintf(&b, "god_scan_duration_seconds %g\n", e.scanDuration.Seconds())
+
+	header("god_scans_total", "counter", "Scans run since start.")
+	fmt.Fprintf(&b, "god_scans_total %d\n", e.scans)
+
+	if !e.lastScan.IsZero() {
+		header("god_scan_timestamp_seconds", "gauge", "Unix time the latest scan finished.")
+		fmt.Fprintf(&b, "god_scan_timestamp_seconds %d\n", e.lastScan.Unix())
+	}
+	return b.String()
+}
+
+// labelValue quotes and escapes a label value for the text format
+func labelValue(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	return `"` + r.Replace(s) + `"`
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
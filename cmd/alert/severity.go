@@ -0,0 +1,219 @@
+package alert
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Exit codes of list and scan when alerts are firing, so cron jobs and shell
+// prompts can react. 1 stays reserved for errors.
+const (
+	exitError    = 1
+	exitWarning  = 2
+	exitCritical = 3
+)
+
+// SeverityConfig is the severity section of config.yaml
+//
+//	alert:
+//	  severity:
+//	    order: [page, critical, error, warning, info]
+type SeverityConfig struct {
+	// Order lists severities from most to least severe and must include
+	// "critical" and "warning". Severities up to "critical" count as
+	// critical, the rest up to "warning" as warning.
+	// Unlisted severities sort after the listed ones, alerts without one last.
+	Order []string `yaml:"order"`
+}
+
+var defaultSeverityOrder = []string{"critical", "warning", "info"}
+
+// severityLevel buckets a severity for icons, colors and exit codes
+type severityLevel int
+
+const (
+	levelNone severityLevel = iota
+	levelInfo
+	levelWarning
+	levelCritical
+)
+
+// display holds the output settings of the current command
+var display = struct {
+	color bool
+	order []string
+}{order: defaultSeverityOrder}
+
+// addDisplayFlags registers --no-color
+func addDisplayFlags(fs *flag.FlagSet) *bool {
+	return fs.Bool("no-color", false, "Disable colors (also set by NO_COLOR)")
+}
+
+// configureDisplay enables colors on a terminal unless disabled and loads the
+// severity order from config
+func configureDisplay(noColor bool) {
+	display.color = !noColor && os.Getenv("NO_COLOR") == "" && os.Getenv("TERM") != "dumb" && isTerminal(os.Stdout)
+
+	cfg, err := loadAlertConfig()
+	if err != nil {
+		fmt.Printf("❌ Error loading config: %v\n", err)
+		os.Exit(1)
+	}
+	if len(cfg.Severity.Order) == 0 {
+		return
+	}
+
+	order, err := parseSeverityOrder(cfg.Severity.Order)
+	if err != nil {
+		fmt.Printf("❌ Error: %v in severity.order of %s\n", err, configPath())
+		os.Exit(1)
+	}
+	display.order = order
+}
+
+// parseSeverityOrder normalizes a configured order. It must rank both
+// critical and warning, in that order, as they set the levels.
+func parseSeverityOrder(list []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var order []string
+	for _, s := range list {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if s == "" || seen[s] {
+			return nil, fmt.Errorf("empty or duplicate severity '%s'", s)
+		}
+		seen[s] = true
+		order = append(order, s)
+	}
+	critical, warning := indexOf(order, "critical"), indexOf(order, "warning")
+	switch {
+	case critical < 0 || warning < 0:
+		return nil, fmt.Errorf("critical and warning must both be listed")
+	case critical > warning:
+		return nil, fmt.Errorf("critical must come before warning")
+	}
+	return order, nil
+}
+
+// severityRank orders severities: listed ones by position, then unlisted
+// ones, then none
+func severityRank(severity string) int {
+	severity = strings.ToLower(severity)
+	if severity == "" {
+		return len(display.order) + 1
+	}
+	for i, s := range display.order {
+		if s == severity {
+			return i
+		}
+	}
+	return len(display.order)
+}
+
+func alertLevel(a Alert) severityLevel {
+	severity := a.Labels["severity"]
+	rank := severityRank(severity)
+	switch {
+	case severity == "":
+		return levelNone
+	case rank <= indexOf(display.order, "critical"):
+		return levelCritical
+	case rank <= indexOf(display.order, "warning"):
+		return levelWarning
+	}
+	return levelInfo
+}
+
+func indexOf(list []string, s string) int {
+	for i, v := range list {
+		if v == s {
+			return i
+		}
+	}
+	return -1
+}
+
+// sortAlerts orders alerts by severity, then oldest first
+func sortAlerts(alerts []Alert) {
+	sort.SliceStable(alerts, func(i, j int) bool {
+		ri, rj := severityRank(alerts[i].Labels["severity"]), severityRank(alerts[j].Labels["severity"])
+		if ri != rj {
+			return ri < rj
+		}
+		if alerts[i].StartsAt != alerts[j].StartsAt {
+			return alerts[i].StartsAt < alerts[j].StartsAt
+		}
+		return alerts[i].Labels["alertname"] < alerts[j].Labels["alertname"]
+	})
+}
+
+// severityExitCode returns the exit code for the most severe alert
+func severityExitCode(alerts []Alert) int {
+	worst := levelNone
+	for _, a := range alerts {
+		if l := alertLevel(a); l > worst {
+			worst = l
+		}
+	}
+	switch worst {
+	case levelCritical:
+		return exitCritical
+	case levelWarning:
+		return exitWarning
+	}
+	return 0
+}
+
+// scanExitCode combines the severity exit code with failed clusters: the
+// highest code wins, so a warning or critical alert anywhere takes
+// precedence over a cluster that couldn't be scanned, which still turns an
+// otherwise clean scan into 1
+func scanExitCode(results []ClusterAlerts) int {
+	var all []Alert
+	failed := false
+	for _, r := range results {
+		all = append(all, r.Alerts...)
+		failed = failed || r.Err != ""
+	}
+	code := severityExitCode(all)
+	if code == 0 && failed {
+		return exitError
+	}
+	return code
+}
+
+// --- Rendering ---
+
+var levelIcons = map[severityLevel]string{
+	levelCritical: "🔴",
+	levelWarning:  "🟠",
+	levelInfo:     "🔵",
+	levelNone:     "⚪",
+}
+
+var levelColors = map[severityLevel]string{
+	levelCritical: "\033[1;31m",
+	levelWarning:  "\033[33m",
+	levelInfo:     "\033[36m",
+}
+
+// colorize wraps s in the color of the alert's severity when colors are on
+func colorize(a Alert, s string) string {
+	code, ok := levelColors[alertLevel(a)]
+	if !display.color || !ok {
+		return s
+	}
+	return code + s + "\033[0m"
+}
+
+// firingFor renders how long the alert has been firing, e.g. "firing 3h12m"
+func firingFor(a Alert, now time.Time) string {
+	start, err := time.Parse(time.RFC3339, a.StartsAt)
+	if err != nil {
+		return ""
+	}
+	return "firing " + formatDuration(now.Sub(start))
+}
@@ -0,0 +1,108 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// defaultSilenceDuration is offered when silencing an alert interactively
+const defaultSilenceDuration = "2h"
+
+// Silence is an Alertmanager API v2 silence. Status and UpdatedAt are only
+// set on silences read back from Alertmanager.
+type Silence struct {
+	ID        string           `json:"id,omitempty"`
+	Matchers  []SilenceMatcher `json:"matchers"`
+	StartsAt  time.Time        `json:"startsAt"`
+	EndsAt    time.Time        `json:"endsAt"`
+	CreatedBy string           `json:"createdBy"`
+	Comment   string           `json:"comment"`
+	UpdatedAt *time.Time       `json:"updatedAt,omitempty"`
+	Status    *struct {
+		State string `json:"state"` // active, pending or expired
+	} `json:"status,omitempty"`
+}
+
+// SilenceMatcher is one label matcher of a silence
+type SilenceMatcher struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	IsRegex bool   `json:"isRegex"`
+	IsEqual bool   `json:"isEqual"`
+}
+
+// State returns the silence state, or "" when Alertmanager didn't report one
+func (s Silence) State() string {
+	if s.Status == nil {
+		return ""
+	}
+	return s.Status.State
+}
+
+// String renders the matchers the way amtool does, e.g. {alertname="A"}
+func (s Silence) String() string {
+	var ms []*Matcher
+	for _, m := range s.Matchers {
+		op := "="
+		switch {
+		case m.IsRegex && m.IsEqual:
+			op = "=~"
+		case m.IsRegex:
+			op = "!~"
+		case !m.IsEqual:
+			op = "!="
+		}
+		ms = append(ms, &Matcher{Name: m.Name, Op: op, Value: m.Value})
+	}
+	return formatMatchers(ms)
+}
+
+// silenceForAlert builds a silence matching exactly this alert's labels
+func silenceForAlert(a Alert, d time.Duration, comment string) Silence {
+	now := time.Now().UTC()
+	s := Silence{
+		StartsAt:  now,
+		EndsAt:    now.Add(d),
+		CreatedBy: auditUser(),
+		Comment:   comment,
+	}
+	for _, k := range sortedKeys(a.Labels) {
+		s.Matchers = append(s.Matchers, SilenceMatcher{Name: k, Value: a.Labels[k], IsEqual: true})
+	}
+	return s
+}
+
+// fetchSilences returns every silence Alertmanager knows, including
+// expired ones it still retains
+func fetchSilences(t Target) ([]Silence, error) {
+	body, err := alertmanagerGet(context.Background(), t, "/api/v2/silences")
+	if err != nil {
+		return nil, err
+	}
+	var silences []Silence
+	if err := json.Unmarshal(body, &silences); err != nil {
+		return nil, fmt.Errorf("failed to parse silences: %v", err)
+	}
+	return silences, nil
+}
+
+// createSilence posts a new silence and returns its ID
+func createSilence(t Target, s Silence) (string, error) {
+	body, err := json.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+	resp, err := alertmanagerPost(context.Background(), t, "/api/v2/silences", body)
+	if err != nil {
+		return "", err
+	}
+	var created struct {
+		SilenceID string `json:"silenceID"`
+	}
+	if err := json.Unmarshal(resp, &created); err != nil || created.SilenceID == "" {
+		return "", fmt.Errorf("unexpected response from Alertmanager: %s", string(resp))
+	}
+	return created.SilenceID, nil
+}
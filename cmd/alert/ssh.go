@@ -0,0 +1,102 @@
+package alert
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+)
+
+// sshControlPersist keeps an idle shared connection open between the alert
+// fetch and the diagnostics of the same host
+const sshControlPersist = "5m"
+
+// sshControlDir holds the ControlMaster sockets. It lives in
+// $XDG_RUNTIME_DIR (default ~/.ssh), which only the user can write to, and
+// not in the state dir because socket paths are limited to ~100 bytes.
+func sshControlDir() string {
+	base := os.Getenv("XDG_RUNTIME_DIR")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return ""
+		}
+		base = filepath.Join(home, ".ssh")
+	}
+	dir := filepath.Join(base, "god-ssh")
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return ""
+	}
+	// Refuse a symlink or a directory others can reach into: whoever can
+	// write there can hijack the shared sessions
+	if fi, err := os.Lstat(dir); err != nil || !fi.IsDir() || fi.Mode().Perm()&0o077 != 0 {
+		return ""
+	}
+	return dir
+}
+
+// sshOptions returns the connection options for a target. Connections are
+// shared through a ControlMaster so each host authenticates once per run.
+func sshOptions(t Target) []string {
+	var args []string
+	if dir := sshControlDir(); dir != "" {
+		args = append(args,
+			"-o", "ControlMaster=auto",
+			"-o", "ControlPath="+filepath.Join(dir, "%C"),
+			"-o", "ControlPersist="+sshControlPersist)
+	}
+	if t.SSHPort != 0 {
+		args = append(args, "-p", strconv.Itoa(t.SSHPort))
+	}
+	if t.Jump != "" {
+		args = append(args, "-J", t.Jump)
+	}
+	return args
+}
+
+// sshCommand runs remoteCmd on the target with a PTY (-t), so PAM can
+// request the YubiKey touch or sudo password
+func sshCommand(ctx context.Context, t Target, remoteCmd string) *exec.Cmd {
+	args := append([]string{"-t"}, sshOptions(t)...)
+	args = append(args, t.Server, remoteCmd)
+	return exec.CommandContext(ctx, "ssh", args...)
+}
+
+// sshMaster identifies the shared connection of a target, like the %C in
+// its ControlPath
+type sshMaster struct {
+	server string
+	port   int
+	jump   string
+}
+
+// closeDroppedSSHMasters stops the shared connections of old targets that
+// are not in current, for commands that resolve their targets again on
+// every scan. Connections still in use are kept.
+func closeDroppedSSHMasters(old, current []Target) {
+	kept := make(map[sshMaster]bool)
+	for _, t := range current {
+		kept[sshMaster{t.Server, t.SSHPort, t.Jump}] = true
+	}
+	var dropped []Target
+	for _, t := range old {
+		if !kept[sshMaster{t.Server, t.SSHPort, t.Jump}] {
+			dropped = append(dropped, t)
+		}
+	}
+	closeSSHMasters(dropped)
+}
+
+// closeSSHMasters stops the shared connections opened for the targets
+func closeSSHMasters(targets []Target) {
+	for _, t := range targets {
+		if !t.IsSSH() {
+			continue
+		}
+		args := append([]string{"-O", "exit"}, sshOptions(t)...)
+		args = append(args, t.Server)
+		// Fails harmlessly when no master is running (e.g. host unreachable)
+		audited(t, exec.Command("ssh", args...), "", runOnly)
+	}
+}
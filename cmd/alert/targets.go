@@ -0,0 +1,308 @@
+package alert
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path"
+	"regexp"
+	"strings"
+	"syscall"
+)
+
+// Target is one place alerts are fetched from and diagnostics run against:
+// an SSH server, a Teleport cluster or a kubeconfig context
+type Target struct {
+	Name string
+	// Server is the SSH connection string; empty for kube targets
+	Server string
+	// SSHPort and Jump (ssh -J) come from the inventory
+	SSHPort int
+	Jump    string
+	// Namespace and Service override the discovered Alertmanager service
+	Namespace string
+	Service   string
+	// Context is passed as `kubectl --context` (and to velero and helm);
+	// empty means the current one
+	Context string
+	// Cluster is the Teleport cluster to `tsh kube login` to before use
+	Cluster string
+}
+
+// IsSSH reports whether commands for this target run over SSH
+func (t Target) IsSSH() bool {
+	return t.Server != ""
+}
+
+// Kind names the source of the target for log output
+func (t Target) Kind() string {
+	switch {
+	case t.Server != "":
+		return "ssh"
+	case t.Cluster != "":
+		return "teleport"
+	case t.Context != "":
+		return "context"
+	}
+	return "current context"
+}
+
+// Connect prepares the target for use. Teleport clusters are switched to
+// with `tsh kube login`; other targets need no preparation.
+func (t Target) Connect() error {
+	if t.Cluster == "" {
+		return nil
+	}
+	return loginCluster(t.Cluster)
+}
+
+// ClusterSource discovers targets for a scan
+type ClusterSource interface {
+	Targets() ([]Target, error)
+	String() string
+}
+
+// --- Teleport ---
+
+// teleportSource selects Teleport kube clusters by a name regex and/or
+// cluster labels. A plain word still matches as a substring.
+type teleportSource struct {
+	filter  string
+	labels  string
+	refresh bool
+}
+
+func (s teleportSource) Targets() ([]Target, error) {
+	nameRe, err := regexp.Compile(s.filter)
+	if err != nil {
+		return nil, fmt.Errorf("invalid --filter regex '%s': %v", s.filter, err)
+	}
+	labels, err := ParseMatchers(splitMatchers(s.labels))
+	if err != nil {
+		return nil, fmt.Errorf("invalid --labels: %v", err)
+	}
+
+	if err := ensureTeleportSession(); err != nil {
+		return nil, err
+	}
+	clusters, err := teleportClusters(s.refresh)
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []Target
+	for _, c := range clusters {
+		if nameRe.MatchString(c.Name) && matchesAll(labels, c.Labels) {
+			targets = append(targets, Target{Name: c.Name, Cluster: c.Name})
+		}
+	}
+	return targets, nil
+}
+
+func (s teleportSource) String() string {
+	var parts []string
+	if s.filter != "" {
+		parts = append(parts, fmt.Sprintf("matching '%s'", s.filter))
+	}
+	if s.labels != "" {
+		parts = append(parts, fmt.Sprintf("with labels %s", s.labels))
+	}
+	return "Teleport clusters " + strings.Join(parts, " ")
+}
+
+// --- Kubeconfig ---
+
+// kubeconfigSource selects contexts from $KUBECONFIG by glob. Contexts are
+// addressed with --context on every call, so the current context is never
+// switched and several sources can be combined in one run.
+type kubeconfigSource struct {
+	pattern string
+}
+
+func (s kubeconfigSource) Targets() ([]Target, error) {
+	if _, err := path.Match(s.pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid --contexts pattern '%s': %v", s.pattern, err)
+	}
+
+	out, err := audited(Target{}, exec.Command("kubectl", "config", "get-contexts", "-o", "name"), "", (*exec.Cmd).Output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list kubeconfig contexts: %v", err)
+	}
+
+	var targets []Target
+	for _, name := range strings.Fields(string(out)) {
+		if ok, _ := path.Match(s.pattern, name); ok {
+			targets = append(targets, Target{Name: name, Context: name})
+		}
+	}
+	return targets, nil
+}
+
+func (s kubeconfigSource) String() string {
+	return fmt.Sprintf("kubeconfig contexts matching '%s'", s.pattern)
+}
+
+// --- Resolution ---
+
+// targetFlags are the flags every command uses to select its targets
+type targetFlags struct {
+	filter    string
+	labels    string
+	refresh   bool
+	contexts  string
+	server    string
+	inventory string
+	group     string
+}
+
+func addTargetFlags(fs *flag.FlagSet) *targetFlags {
+	tf := &targetFlags{}
+	fs.StringVar(&tf.filter, "filter", "", "Teleport clusters whose name matches this regex (e.g. prod or '^prod-(eu|us)')")
+	fs.StringVar(&tf.labels, "labels", "", "Teleport clusters with these labels (e.g. env=prod,region=eu)")
+	fs.BoolVar(&tf.refresh, "refresh-clusters", false, "List Teleport clusters again instead of using the cache")
+	fs.StringVar(&tf.contexts, "contexts", "", "Glob of kubeconfig contexts (e.g. 'prod-*')")
+	fs.StringVar(&tf.server, "server", "", "Direct SSH connection string (e.g., ubuntu@192.12.3.1)")
+	fs.StringVar(&tf.inventory, "inventory", "", "Inventory file of SSH servers (default: "+inventoryPath()+")")
+	fs.StringVar(&tf.group, "group", "", "Inventory group to check ('all' for every host)")
+	return tf
+}
+
+// empty reports whether no target was selected
+func (tf *targetFlags) empty() bool {
+	return tf.filter == "" && tf.labels == "" && tf.contexts == "" && tf.server == "" && tf.inventory == "" && tf.group == ""
+}
+
+// sources builds the cluster sources selected on the command line
+func (tf *targetFlags) sources() []ClusterSource {
+	var sources []ClusterSource
+	if tf.inventory != "" || tf.group != "" {
+		path := tf.inventory
+		if path == "" {
+			path = inventoryPath()
+		}
+		sources = append(sources, inventorySource{path: path, group: tf.inventoryGroup()})
+	}
+	if tf.filter != "" || tf.labels != "" {
+		sources = append(sources, teleportSource{filter: tf.filter, labels: tf.labels, refresh: tf.refresh})
+	}
+	if tf.contexts != "" {
+		sources = append(sources, kubeconfigSource{pattern: tf.contexts})
+	}
+	return sources
+}
+
+// inventoryGroup is --group, defaulting to every host of the inventory
+func (tf *targetFlags) inventoryGroup() string {
+	if tf.group == "" {
+		return "all"
+	}
+	return tf.group
+}
+
+// targets returns the SSH server (if any) followed by the targets of every
+// source, in order. A failing source is reported on stderr and skipped, so
+// the output of --json and handoff stays clean.
+func (tf *targetFlags) targets() []Target {
+	var targets []Target
+	if tf.server != "" {
+		targets = append(targets, Target{Name: tf.server, Server: tf.server})
+	}
+
+	for _, src := range tf.sources() {
+		found, err := src.Targets()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+			continue
+		}
+		if len(found) == 0 {
+			fmt.Fprintf(os.Stderr, "⚠️  No %s\n", src)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "🔍 Found %d %s\n", len(found), src)
+		targets = append(targets, found...)
+	}
+	return targets
+}
+
+// String is a short label for the selected targets, used to key saved
+// scans and notification state
+func (tf *targetFlags) String() string {
+	var parts []string
+	if tf.server != "" {
+		parts = append(parts, tf.server)
+	}
+	if tf.filter != "" {
+		parts = append(parts, tf.filter)
+	}
+	if tf.labels != "" {
+		parts = append(parts, "labels="+tf.labels)
+	}
+	if tf.contexts != "" {
+		parts = append(parts, "contexts="+tf.contexts)
+	}
+	if tf.inventory != "" || tf.group != "" {
+		parts = append(parts, "group="+tf.inventoryGroup())
+	}
+	return strings.Join(parts, " ")
+}
+
+// defaultTarget is the target of a command run without target flags: the
+// current kube context, or the host of --am-url/--prom-url
+func defaultTarget() Target {
+	if endpoints.directName != "" {
+		return Target{Name: endpoints.directName}
+	}
+	return Target{Name: getClusterName()}
+}
+
+// forEachTarget calls fn for every selected target, or for the current kube
+// context when none were selected. Shared SSH connections are closed after.
+func forEachTarget(tf *targetFlags, fn func(t Target)) {
+	if tf.empty() {
+		fn(defaultTarget())
+		return
+	}
+
+	targets := tf.targets()
+	defer releaseTargets(targets)
+	defer releaseOnSignal(targets)()
+
+	for _, t := range targets {
+		if err := t.Connect(); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ [%s] Login failed: %v\n", t.Name, err)
+			continue
+		}
+		fn(t)
+	}
+}
+
+// releaseTargets ends a run over targets: shared SSH connections are closed
+// and the kube context selected before any Teleport login is restored
+func releaseTargets(targets []Target) {
+	closeSSHMasters(targets)
+	restoreKubeContext()
+}
+
+// releaseOnSignal releases the targets when the run is interrupted, so
+// Ctrl+C mid-scan doesn't leave the kube context on a Teleport cluster. The
+// returned func stops watching for signals; defer it after releaseTargets.
+func releaseOnSignal(targets []Target) func() {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case s := <-sigs:
+			fmt.Fprintln(os.Stderr, "\n👋 Interrupted, cleaning up")
+			releaseTargets(targets)
+			os.Exit(128 + int(s.(syscall.Signal)))
+		case <-done:
+		}
+	}()
+	return func() {
+		signal.Stop(sigs)
+		close(done)
+	}
+}
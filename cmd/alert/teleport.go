@@ -0,0 +1,246 @@
+package alert
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// defaultTeleportCacheTTL is how long the `tsh kube ls` result is reused
+const defaultTeleportCacheTTL = 10 * time.Minute
+
+// TeleportConfig is the teleport section of the alert config
+type TeleportConfig struct {
+	// CacheTTL is how long the cluster list is reused, e.g. "30m"; "0"
+	// lists the clusters on every run
+	CacheTTL string `yaml:"cache_ttl"`
+	// Proxy is passed to `tsh login --proxy` when the session has expired
+	Proxy string `yaml:"proxy"`
+}
+
+// TSHCluster represents the JSON output from `tsh kube ls`
+type TSHCluster struct {
+	Name   string            `json:"kube_cluster_name"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// tshProfile is the part of `tsh status --format=json` god relies on
+type tshProfile struct {
+	ProfileURL  string    `json:"profile_url"`
+	Username    string    `json:"username"`
+	Cluster     string    `json:"cluster"`
+	KubeCluster string    `json:"kubernetes_cluster"`
+	ValidUntil  time.Time `json:"valid_until"`
+}
+
+// teleportCache is the cluster list saved between runs
+type teleportCache struct {
+	Profile  string       `json:"profile"`
+	Fetched  time.Time    `json:"fetched"`
+	Clusters []TSHCluster `json:"clusters"`
+}
+
+// teleport tracks the session and the kube context for the current run
+var teleport struct {
+	// profile identifies the logged-in proxy and user; empty until checked.
+	// It is checked again once validUntil has passed.
+	profile    string
+	validUntil time.Time
+	prompted   bool
+	// original is the kube context selected before the first
+	// `tsh kube login`; switched is set until it has been restored
+	original string
+	recorded bool
+	switched bool
+}
+
+// --- Session ---
+
+// ensureTeleportSession checks `tsh status` once per session, so long-running
+// commands notice when it expires. An expired or missing session runs `tsh
+// login` once per run when attached to a terminal.
+func ensureTeleportSession() error {
+	if teleport.profile != "" && time.Now().Before(teleport.validUntil) {
+		return nil
+	}
+	teleport.profile = ""
+	if _, err := exec.LookPath("tsh"); err != nil {
+		return fmt.Errorf("'tsh' is not installed")
+	}
+
+	profile, reason := tshSession()
+	if profile == nil {
+		if teleport.prompted || !interactive() {
+			return fmt.Errorf("Teleport %s, run 'tsh login' first", reason)
+		}
+		teleport.prompted = true
+
+		args := []string{"login"}
+		if cfg, err := loadAlertConfig(); err == nil && cfg.Teleport.Proxy != "" {
+			args = append(args, "--proxy="+cfg.Teleport.Proxy)
+		}
+		fmt.Fprintf(os.Stderr, "🔐 Teleport %s, running tsh %s\n", reason, strings.Join(args, " "))
+		cmd := exec.Command("tsh", args...)
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stderr, os.Stderr
+		if _, err := audited(Target{}, cmd, "", runOnly); err != nil {
+			return fmt.Errorf("tsh login failed: %v", err)
+		}
+		if profile, reason = tshSession(); profile == nil {
+			return fmt.Errorf("Teleport %s after tsh login", reason)
+		}
+	}
+
+	teleport.profile = profile.ProfileURL + "|" + profile.Username
+	teleport.validUntil = profile.ValidUntil
+	return nil
+}
+
+// interactive reports whether stdin is a terminal someone can log in on;
+// unlike isTerminal it rejects /dev/null
+func interactive() bool {
+	if !isTerminal(os.Stdin) {
+		return false
+	}
+	_, err := stty("-g")
+	return err == nil
+}
+
+// tshSession returns the active profile, or nil and why it can't be used
+func tshSession() (*tshProfile, string) {
+	// tsh exits non-zero without a valid session but may still print JSON
+	out, _ := audited(Target{}, exec.Command("tsh", "status", "--format=json"), "", (*exec.Cmd).Output)
+	var status struct {
+		Active *tshProfile `json:"active"`
+	}
+	if err := json.Unmarshal(out, &status); err != nil || status.Active == nil || status.Active.ProfileURL == "" {
+		return nil, "is not logged in"
+	}
+	if !status.Active.ValidUntil.After(time.Now()) {
+		return nil, fmt.Sprintf("session expired at %s", status.Active.ValidUntil.Local().Format("2006-01-02 15:04"))
+	}
+	return status.Active, ""
+}
+
+// --- Cluster List ---
+
+// teleportClusters returns the kube clusters of the Teleport session,
+// from the cache when it is younger than the configured TTL
+func teleportClusters(refresh bool) ([]TSHCluster, error) {
+	ttl, err := teleportCacheTTL()
+	if err != nil {
+		return nil, err
+	}
+
+	path := teleportCachePath()
+	if ttl > 0 && !refresh && path != "" {
+		var cache teleportCache
+		if data, err := os.ReadFile(path); err == nil && json.Unmarshal(data, &cache) == nil &&
+			cache.Profile == teleport.profile && time.Since(cache.Fetched) < ttl {
+			fmt.Fprintf(os.Stderr, "🔍 Using Teleport clusters cached %s ago (--refresh-clusters to update)\n", formatDuration(time.Since(cache.Fetched)))
+			return cache.Clusters, nil
+		}
+	}
+
+	fmt.Fprintln(os.Stderr, "🔍 Fetching clusters from Teleport...")
+	output, err := audited(Target{}, exec.Command("tsh", "kube", "ls", "--format=json"), "", (*exec.Cmd).Output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run 'tsh kube ls': %v", err)
+	}
+	var clusters []TSHCluster
+	if err := json.Unmarshal(output, &clusters); err != nil {
+		return nil, fmt.Errorf("failed to parse tsh output: %v", err)
+	}
+
+	if ttl > 0 && path != "" {
+		cache := teleportCache{Profile: teleport.profile, Fetched: time.Now(), Clusters: clusters}
+		if err := writeTeleportCache(path, cache); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  Could not cache the Teleport cluster list: %v\n", err)
+		}
+	}
+	return clusters, nil
+}
+
+// cacheTTL parses CacheTTL, where "0" turns the cache off
+func (c TeleportConfig) cacheTTL() (time.Duration, error) {
+	switch c.CacheTTL {
+	case "":
+		return defaultTeleportCacheTTL, nil
+	case "0":
+		return 0, nil
+	}
+	return ParseLongDuration(c.CacheTTL)
+}
+
+func teleportCacheTTL() (time.Duration, error) {
+	cfg, err := loadAlertConfig()
+	if err != nil {
+		return 0, err
+	}
+	ttl, err := cfg.Teleport.cacheTTL()
+	if err != nil {
+		return 0, fmt.Errorf("invalid teleport.cache_ttl '%s' in %s", cfg.Teleport.CacheTTL, configPath())
+	}
+	return ttl, nil
+}
+
+func teleportCachePath() string {
+	dir := stateDir()
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "teleport-clusters.json")
+}
+
+func writeTeleportCache(path string, cache teleportCache) error {
+	data, err := json.MarshalIndent(cache, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o600)
+}
+
+// --- Kube Context ---
+
+// loginCluster switches the current kube context to a Teleport cluster.
+// The context selected before the first switch is remembered so that
+// restoreKubeContext can put it back.
+func loginCluster(cluster string) error {
+	// Long-running commands log in again after the session expired
+	if err := ensureTeleportSession(); err != nil {
+		return err
+	}
+	if !teleport.recorded {
+		teleport.recorded = true
+		if current := getClusterName(); current != "Unknown" {
+			teleport.original = current
+		}
+	}
+
+	loginCmd := exec.Command("tsh", "kube", "login", cluster)
+	t := Target{Name: cluster, Cluster: cluster}
+	teleport.switched = true
+	if out, err := audited(t, loginCmd, "", (*exec.Cmd).CombinedOutput); err != nil {
+		return fmt.Errorf("%v\n%s", err, string(out))
+	}
+	return nil
+}
+
+// restoreKubeContext selects the kube context that was current before the
+// scan logged in to Teleport clusters. It does nothing if none was switched.
+func restoreKubeContext() {
+	if !teleport.switched || teleport.original == "" {
+		return
+	}
+	teleport.switched = false
+	cmd := exec.Command("kubectl", "config", "use-context", teleport.original)
+	if out, err := audited(Target{}, cmd, "", (*exec.Cmd).CombinedOutput); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Could not restore kube context %s: %v %s\n", teleport.original, err, strings.TrimSpace(string(out)))
+	}
+}
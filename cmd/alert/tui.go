@@ -0,0 +1,850 @@
+package alert
+
+import (
+	"flag"
+	"fmt"
+	"god/cmd/config"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// tuiPane is the pane the cursor keys act on
+type tuiPane int
+
+const (
+	paneClusters tuiPane = iota
+	paneAlerts
+	paneDetail
+)
+
+// tuiPrompt is a one-line input shown in the status line
+type tuiPrompt struct {
+	label  string
+	value  string
+	submit func(value string)
+}
+
+// tuiState is everything the dashboard shows. The screen is redrawn from it
+// after every key press and once a second.
+type tuiState struct {
+	tf       *targetFlags
+	interval time.Duration
+
+	targets []Target
+	scans   []clusterScan
+	scanned time.Time
+
+	filterText string
+	filter     []*Matcher
+
+	cluster, alert int
+	focus          tuiPane
+	scroll         int
+	help           bool
+
+	// diagnosis output, kept for the alert it was run for
+	diagKey  string
+	diagOut  []string
+	diagJump bool
+
+	status string
+	prompt *tuiPrompt
+	quit   bool
+
+	// saved terminal settings while the dashboard owns the screen
+	tty    string
+	active bool
+}
+
+// tuiFlags holds the flags of god alert tui
+type tuiFlags struct {
+	*flag.FlagSet
+	targets   *targetFlags
+	endpoints *endpointFlags
+	rulesPath *string
+	interval  *time.Duration
+	match     *string
+	noColor   *bool
+}
+
+func newTuiFlags() *tuiFlags {
+	f := &tuiFlags{FlagSet: flag.NewFlagSet("tui", flag.ExitOnError)}
+	f.targets = addTargetFlags(f.FlagSet)
+	f.endpoints = addEndpointFlags(f.FlagSet, true)
+	f.rulesPath = f.String("rules-dir", rulesDir(), "Directory of YAML diagnostic rule files")
+	f.interval = f.Duration("interval", time.Minute, "Time between automatic refreshes (0 refreshes only on r)")
+	f.match = f.String("match", "", `Initial alert filter, e.g. severity="critical",namespace=~"kube-.*"`)
+	f.noColor = addDisplayFlags(f.FlagSet)
+	return f
+}
+
+func runTUI(args []string) {
+	f := newTuiFlags()
+	tf, ef, rulesPath, interval, match, noColor := f.targets, f.endpoints, f.rulesPath, f.interval, f.match, f.noColor
+	config.Apply(f.FlagSet, "alert")
+	f.Parse(args)
+	configureEndpoints(ef)
+	configureDisplay(*noColor)
+
+	if !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+		fmt.Println("❌ Error: god alert tui needs an interactive terminal (use god alert list in scripts)")
+		os.Exit(1)
+	}
+
+	u := &tuiState{tf: tf, interval: *interval}
+	if err := u.setFilter(*match); err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	loadRuleFiles(*rulesPath)
+
+	// The first scan runs before taking over the screen, so login and
+	// YubiKey prompts show up as usual
+	u.refresh()
+
+	// Put the terminal back on kill; Ctrl+C is read as a key while the
+	// dashboard is shown and only interrupts commands while suspended
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		for s := range sigs {
+			if s == syscall.SIGTERM {
+				u.leave()
+				os.Exit(1)
+			}
+		}
+	}()
+
+	if err := u.enter(); err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	// refresh replaces the targets, so release the ones of the last scan
+	defer func() { releaseTargets(u.targets) }()
+	defer u.leave()
+	u.loop()
+}
+
+// --- Terminal ---
+
+// stty runs stty on the controlling terminal
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// enter switches to the alternate screen with unbuffered input. Reads
+// return after at most a second without a key, which drives the clock.
+func (u *tuiState) enter() error {
+	saved, err := stty("-g")
+	if err != nil {
+		return fmt.Errorf("failed to read terminal settings: %v", err)
+	}
+	if _, err := stty("-icanon", "-echo", "-isig", "-ixon", "min", "0", "time", "10"); err != nil {
+		return fmt.Errorf("failed to set terminal mode: %v", err)
+	}
+	u.tty, u.active = saved, true
+	fmt.Print("\033[?1049h\033[?25l")
+	return nil
+}
+
+// leave restores the screen and terminal settings; safe to call twice
+func (u *tuiState) leave() {
+	if !u.active {
+		return
+	}
+	u.active = false
+	fmt.Print("\033[?25h\033[?1049l")
+	stty(u.tty)
+}
+
+// suspended runs fn on the normal screen, so commands can print progress
+// and ask for passwords, then returns to the dashboard
+func (u *tuiState) suspended(fn func()) {
+	u.leave()
+	fn()
+	if err := u.enter(); err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		u.quit = true
+	}
+}
+
+func terminalSize() (rows, cols int) {
+	out, err := stty("size")
+	if err == nil {
+		if f := strings.Fields(out); len(f) == 2 {
+			rows, _ = strconv.Atoi(f[0])
+			cols, _ = strconv.Atoi(f[1])
+		}
+	}
+	if rows <= 0 || cols <= 0 {
+		return 24, 80
+	}
+	return rows, cols
+}
+
+// --- Input ---
+
+func (u *tuiState) loop() {
+	buf := make([]byte, 64)
+	for !u.quit {
+		if u.interval > 0 && u.prompt == nil && time.Since(u.scanned) >= u.interval {
+			u.suspended(u.refresh)
+			continue
+		}
+		u.render()
+
+		n, err := os.Stdin.Read(buf)
+		if err != nil && err != io.EOF {
+			u.status = fmt.Sprintf("❌ Reading input: %v", err)
+			u.quit = true
+		}
+		// A read that timed out without a key returns io.EOF
+		for _, k := range parseKeys(buf[:n]) {
+			u.handleKey(k)
+		}
+	}
+}
+
+// escapeKeys names the escape sequences of the keys the dashboard uses
+var escapeKeys = map[string]string{
+	"A": "up", "B": "down", "C": "right", "D": "left",
+	"H": "home", "F": "end", "Z": "backtab",
+	"1~": "home", "7~": "home", "4~": "end", "8~": "end",
+	"5~": "pgup", "6~": "pgdn",
+}
+
+// parseKeys splits raw input into key names ("up", "enter", ...) and
+// typed characters
+func parseKeys(b []byte) []string {
+	var keys []string
+	for len(b) > 0 {
+		switch c := b[0]; {
+		case c == 0x1b && len(b) > 2 && (b[1] == '[' || b[1] == 'O'):
+			// CSI or SS3 sequence: parameters up to a final byte
+			i := 2
+			for i < len(b) && (b[i] < 0x40 || b[i] > 0x7e) {
+				i++
+			}
+			if i == len(b) {
+				return keys
+			}
+			if k, ok := escapeKeys[string(b[2:i+1])]; ok {
+				keys = append(keys, k)
+			}
+			b = b[i+1:]
+			continue
+		case c == 0x1b:
+			keys = append(keys, "esc")
+		case c == '\r' || c == '\n':
+			keys = append(keys, "enter")
+		case c == '\t':
+			keys = append(keys, "tab")
+		case c == 0x7f || c == 0x08:
+			keys = append(keys, "backspace")
+		case c == 0x03:
+			keys = append(keys, "ctrl-c")
+		case c == 0x15:
+			keys = append(keys, "ctrl-u")
+		case c < 0x20:
+			// other control characters are ignored
+		default:
+			r, size := utf8.DecodeRune(b)
+			keys = append(keys, string(r))
+			b = b[size:]
+			continue
+		}
+		b = b[1:]
+	}
+	return keys
+}
+
+func (u *tuiState) handleKey(k string) {
+	if u.prompt != nil {
+		u.editPrompt(k)
+		return
+	}
+	u.status = ""
+
+	switch k {
+	case "q", "ctrl-c":
+		u.quit = true
+	case "up", "k":
+		u.move(-1)
+	case "down", "j":
+		u.move(1)
+	case "pgup":
+		u.move(-10)
+	case "pgdn":
+		u.move(10)
+	case "home", "g":
+		u.move(-1 << 30)
+	case "end", "G":
+		u.move(1 << 30)
+	case "tab":
+		u.focus = (u.focus + 1) % 3
+	case "backtab":
+		u.focus = (u.focus + 2) % 3
+	case "left", "h":
+		if u.focus > paneClusters {
+			u.focus--
+		}
+	case "right", "l", "enter":
+		if u.focus < paneDetail {
+			u.focus++
+		}
+	case "esc":
+		u.help = false
+		u.diagKey, u.diagOut = "", nil
+	case "?":
+		u.help = !u.help
+		u.scroll = 0
+	case "r":
+		u.suspended(u.refresh)
+	case "/":
+		u.prompt = &tuiPrompt{label: "Filter (matchers, empty for all)", value: u.filterText, submit: func(v string) {
+			if err := u.setFilter(v); err != nil {
+				u.status = "❌ " + err.Error()
+				return
+			}
+			u.alert, u.scroll = 0, 0
+		}}
+	case "s":
+		u.silenceSelected()
+	case "d":
+		u.diagnoseSelected()
+	}
+}
+
+func (u *tuiState) editPrompt(k string) {
+	p := u.prompt
+	switch k {
+	case "enter":
+		u.prompt = nil
+		p.submit(strings.TrimSpace(p.value))
+	case "esc", "ctrl-c":
+		u.prompt = nil
+		u.status = "Cancelled"
+	case "backspace":
+		if _, size := utf8.DecodeLastRuneInString(p.value); size > 0 {
+			p.value = p.value[:len(p.value)-size]
+		}
+	case "ctrl-u":
+		p.value = ""
+	default:
+		if r, _ := utf8.DecodeRuneInString(k); utf8.RuneCountInString(k) == 1 && unicode.IsPrint(r) {
+			p.value += k
+		}
+	}
+}
+
+// move shifts the cursor of the focused pane
+func (u *tuiState) move(delta int) {
+	switch u.focus {
+	case paneClusters:
+		u.cluster = clampIndex(u.cluster+delta, len(u.scans))
+		u.alert, u.scroll = 0, 0
+	case paneAlerts:
+		u.alert = clampIndex(u.alert+delta, len(u.visibleAlerts()))
+		u.scroll = 0
+	case paneDetail:
+		// the upper bound depends on the pane height, see render
+		u.scroll = max(0, u.scroll+delta)
+	}
+}
+
+func clampIndex(i, n int) int {
+	return max(0, min(i, n-1))
+}
+
+// --- Actions ---
+
+// refresh scans every target again, keeping the selected cluster and alert
+func (u *tuiState) refresh() {
+	var cluster, alert string
+	if t, a, ok := u.selected(); ok {
+		cluster, alert = t.Name, a.Key()
+	} else if u.cluster < len(u.scans) {
+		cluster = u.scans[u.cluster].name
+	}
+
+	targets := serveTargets(u.tf)
+	closeDroppedSSHMasters(u.targets, targets)
+	u.targets = targets
+	fmt.Printf("🔄 Scanning %d cluster(s)...\n", len(u.targets))
+	u.scans = scanAll(u.targets)
+	u.scanned = time.Now()
+
+	u.cluster, u.alert = 0, 0
+	for i, s := range u.scans {
+		if s.name == cluster {
+			u.cluster = i
+		}
+	}
+	for i, a := range u.visibleAlerts() {
+		if a.Key() == alert {
+			u.alert = i
+		}
+	}
+}
+
+func (u *tuiState) setFilter(text string) error {
+	ms, err := ParseMatchers(splitMatchers(text))
+	if err != nil {
+		return err
+	}
+	u.filterText, u.filter = strings.TrimSpace(text), ms
+	return nil
+}
+
+// visibleAlerts returns the selected cluster's alerts matching the filter
+func (u *tuiState) visibleAlerts() []Alert {
+	if u.cluster >= len(u.scans) {
+		return nil
+	}
+	return u.filtered(u.scans[u.cluster].alerts)
+}
+
+func (u *tuiState) filtered(alerts []Alert) []Alert {
+	var out []Alert
+	for _, a := range alerts {
+		if matchesAll(u.filter, a.Labels) {
+			out = append(out, a)
+		}
+	}
+	sortAlerts(out)
+	return out
+}
+
+// selected returns the alert under the cursor and the target it fires on
+func (u *tuiState) selected() (Target, Alert, bool) {
+	alerts := u.visibleAlerts()
+	if u.alert >= len(alerts) || u.cluster >= len(u.targets) {
+		return Target{}, Alert{}, false
+	}
+	return u.targets[u.cluster], alerts[u.alert], true
+}
+
+// silenceSelected asks for a duration and a comment, then silences exactly
+// the selected alert's label set
+func (u *tuiState) silenceSelected() {
+	t, a, ok := u.selected()
+	if !ok {
+		u.status = "No alert selected"
+		return
+	}
+	name := a.Labels["alertname"]
+
+	u.prompt = &tuiPrompt{label: "Silence " + name + " for", value: defaultSilenceDuration, submit: func(v string) {
+		d, err := ParseLongDuration(v)
+		if err != nil {
+			u.status = fmt.Sprintf("❌ Invalid duration %q (e.g. 30m, 2h, 1d)", v)
+			return
+		}
+		u.prompt = &tuiPrompt{label: "Comment (Esc cancels)", submit: func(comment string) {
+			if comment == "" {
+				u.status = "❌ A comment is required to silence an alert"
+				return
+			}
+			var id string
+			u.suspended(func() {
+				fmt.Printf("🔕 Silencing %s → %s on %s for %s...\n", name, alertTarget(a), t.Name, formatDuration(d))
+				if err = t.Connect(); err == nil {
+					id, err = createSilence(t, silenceForAlert(a, d, comment))
+				}
+				restoreKubeContext()
+			})
+			if err != nil {
+				u.status = fmt.Sprintf("❌ Silence failed: %v", firstLine(err.Error()))
+				return
+			}
+			u.status = fmt.Sprintf("🔕 Silenced %s until %s (%s)", name, time.Now().Add(d).Format("Jan 2 15:04"), id)
+			u.dropAlert(a)
+		}}
+	}}
+}
+
+// dropAlert removes a silenced alert without waiting for the next scan
+func (u *tuiState) dropAlert(a Alert) {
+	s := &u.scans[u.cluster]
+	for i, b := range s.alerts {
+		if b.Key() == a.Key() {
+			s.alerts = append(s.alerts[:i:i], s.alerts[i+1:]...)
+			break
+		}
+	}
+	u.alert = clampIndex(u.alert, len(u.visibleAlerts()))
+	u.scroll = 0
+}
+
+// diagnoseSelected runs the alert's diagnostic rules and shows their output
+// in the detail pane
+func (u *tuiState) diagnoseSelected() {
+	t, a, ok := u.selected()
+	if !ok {
+		u.status = "No alert selected"
+		return
+	}
+	rules := rulesFor(a)
+	if len(rules) == 0 {
+		u.status = fmt.Sprintf("No diagnostic rule for %s", a.Labels["alertname"])
+		return
+	}
+
+	var out strings.Builder
+	u.suspended(func() {
+		fmt.Printf("🩺 Diagnosing %s → %s on %s...\n", a.Labels["alertname"], alertTarget(a), t.Name)
+		defer restoreKubeContext()
+		if err := t.Connect(); err != nil {
+			fmt.Fprintf(&out, "❌ Login failed: %v\n", err)
+			return
+		}
+		for _, rule := range rules {
+			d := &Diagnosis{Target: t, Started: time.Now()}
+			rule.Run(d, a)
+			d.Finished = time.Now()
+			fmt.Fprintf(&out, "🩺 %s (%s)\n%s\n", rule.Name, formatDuration(d.Finished.Sub(d.Started)), d.Output())
+		}
+	})
+
+	u.diagKey = t.Name + "|" + a.Key()
+	u.diagOut = nil
+	for _, line := range strings.Split(strings.TrimRight(strings.ReplaceAll(out.String(), "\r", ""), "\n"), "\n") {
+		u.diagOut = append(u.diagOut, " "+line)
+	}
+	u.focus, u.help, u.diagJump = paneDetail, false, true
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
+
+// --- Rendering ---
+
+func (u *tuiState) render() {
+	rows, cols := terminalSize()
+	if rows < 10 || cols < 50 {
+		fmt.Print("\033[H\033[2J" + fitCells("Terminal too small for god alert tui (q quits)", cols))
+		return
+	}
+
+	leftW := max(20, min(36, cols/4))
+	rightW := cols - leftW - 1
+	body := rows - 3
+	listH := max(4, body*2/5)
+	detailH := body - listH - 1
+
+	total := 0
+	for _, s := range u.scans {
+		total += len(u.filtered(s.alerts))
+	}
+	header := fmt.Sprintf(" god alert tui · %d clusters · %d alerts", len(u.scans), total)
+	if u.filterText != "" {
+		header += " · filter " + u.filterText
+	}
+	header += " · updated " + u.scanned.Format("15:04:05")
+
+	lines := []string{"\033[7m" + fitCells(header, cols) + "\033[0m"}
+	left := u.clusterPane(leftW, body)
+	list := u.alertPane(rightW, listH)
+	detailTitle, detail := u.detailPane(rightW, detailH)
+	for i := 0; i < body; i++ {
+		var right string
+		switch {
+		case i < listH:
+			right = list[i]
+		case i == listH:
+			right = detailTitle
+		default:
+			right = detail[i-listH-1]
+		}
+		lines = append(lines, left[i]+"│"+right)
+	}
+
+	if p := u.prompt; p != nil {
+		lines = append(lines, fitCells(" "+p.label+": "+p.value+"█", cols))
+	} else {
+		lines = append(lines, fitCells(" "+u.status, cols))
+	}
+	keys := " ↑↓ move  ←→/tab pane  r refresh  / filter  s silence  d diagnose  ? help  q quit"
+	lines = append(lines, "\033[2m"+fitCells(keys, cols)+"\033[0m")
+
+	fmt.Print("\033[H" + strings.Join(lines, "\n"))
+}
+
+// row renders one list line, highlighted when the cursor is on it
+func (u *tuiState) row(text string, w int, cursor bool, pane tuiPane, color func(string) string) string {
+	s := fitCells(text, w)
+	switch {
+	case cursor && u.focus == pane:
+		return "\033[7m" + s + "\033[0m"
+	case cursor:
+		return "\033[1m" + s + "\033[0m"
+	case color != nil:
+		return color(s)
+	}
+	return s
+}
+
+func (u *tuiState) clusterPane(w, h int) []string {
+	lines := []string{"\033[1m" + fitCells(fmt.Sprintf(" Clusters (%d)", len(u.scans)), w) + "\033[0m"}
+	start := scrollStart(u.cluster, len(u.scans), h-1)
+	for i := start; i < len(u.scans) && len(lines) < h; i++ {
+		s := u.scans[i]
+		icon, count := "✅", "0"
+		if s.err != nil {
+			icon, count = "⚠️", "err"
+		} else if alerts := u.filtered(s.alerts); len(alerts) > 0 {
+			// sorted by severity, so the first alert is the worst
+			icon, count = levelIcons[alertLevel(alerts[0])], strconv.Itoa(len(alerts))
+		}
+		text := fitCells(" "+icon+" "+s.name, w-len(count)-2) + " " + count + " "
+		lines = append(lines, u.row(text, w, i == u.cluster, paneClusters, nil))
+	}
+	return padLines(lines, w, h)
+}
+
+func (u *tuiState) alertPane(w, h int) []string {
+	if u.cluster >= len(u.scans) {
+		return padLines([]string{fitCells(" No clusters", w)}, w, h)
+	}
+	s := u.scans[u.cluster]
+	alerts := u.visibleAlerts()
+
+	title := fmt.Sprintf(" Alerts on %s (%d)", s.name, len(alerts))
+	if len(alerts) != len(s.alerts) {
+		title = fmt.Sprintf(" Alerts on %s (%d of %d)", s.name, len(alerts), len(s.alerts))
+	}
+	lines := []string{"\033[1m" + fitCells(title, w) + "\033[0m"}
+
+	switch {
+	case s.err != nil:
+		lines = append(lines, fitCells(" ⚠️  "+firstLine(s.err.Error()), w))
+	case len(alerts) == 0 && len(s.alerts) > 0:
+		lines = append(lines, fitCells(" No alerts match the filter", w))
+	case len(alerts) == 0:
+		lines = append(lines, fitCells(" ✅ No active alerts", w))
+	}
+
+	now := time.Now()
+	start := scrollStart(u.alert, len(alerts), h-1)
+	for i := start; i < len(alerts) && len(lines) < h; i++ {
+		a := alerts[i]
+		text := fmt.Sprintf(" %s %s  %s  %s", levelIcons[alertLevel(a)], a.Labels["alertname"], alertTarget(a), firingFor(a, now))
+		lines = append(lines, u.row(text, w, i == u.alert, paneAlerts, func(s string) string { return colorize(a, s) }))
+	}
+	return padLines(lines, w, h)
+}
+
+// detailPane returns the separator line with the pane title and the visible
+// part of the details of the selected alert
+func (u *tuiState) detailPane(w, h int) (string, []string) {
+	title := "Details"
+	var lines []string
+	switch t, a, ok := u.selected(); {
+	case u.help:
+		title, lines = "Help", tuiHelp
+	case ok:
+		lines = u.alertDetail(a, w)
+		if u.diagKey == t.Name+"|"+a.Key() {
+			title = "Details + diagnosis"
+		}
+	case u.cluster < len(u.scans) && u.scans[u.cluster].err != nil:
+		lines = wrapCells(" "+u.scans[u.cluster].err.Error(), w)
+	}
+
+	if u.diagJump {
+		// show the new diagnosis output first
+		u.scroll, u.diagJump = len(lines)-len(u.diagOut)-1, false
+	}
+	u.scroll = max(0, min(u.scroll, len(lines)-h))
+	if len(lines) > h {
+		title += fmt.Sprintf(" %d-%d/%d", u.scroll+1, min(u.scroll+h, len(lines)), len(lines))
+	}
+	titleStyle := "\033[2m"
+	if u.focus == paneDetail {
+		titleStyle = "\033[1m"
+	}
+	head := "── " + title + " "
+	sep := titleStyle + fitCells(head+strings.Repeat("─", max(0, w-cellWidth(head))), w) + "\033[0m"
+
+	var visible []string
+	for i := u.scroll; i < len(lines) && len(visible) < h; i++ {
+		visible = append(visible, fitCells(lines[i], w))
+	}
+	return sep, padLines(visible, w, h)
+}
+
+// alertDetail lays out labels, annotations, links and any diagnosis output,
+// wrapping long text at the pane width
+func (u *tuiState) alertDetail(a Alert, w int) []string {
+	now := time.Now()
+	head := []string{levelIcons[alertLevel(a)] + " " + a.Labels["alertname"]}
+	if sev := a.Labels["severity"]; sev != "" {
+		head = append(head, sev)
+	}
+	head = append(head, firingFor(a, now), shortFingerprint(a))
+	lines := []string{" " + strings.Join(head, " · ")}
+
+	if s := strings.TrimSpace(a.Annotations["summary"]); s != "" {
+		lines = append(lines, wrapCells(" 📝 "+s, w)...)
+	}
+	if d := strings.TrimSpace(a.Annotations["description"]); d != "" {
+		for _, line := range strings.Split(d, "\n") {
+			lines = append(lines, wrapCells("    "+strings.TrimSpace(line), w)...)
+		}
+	}
+	if r := runbookURL(a); r != "" {
+		lines = append(lines, " 📖 Runbook:   "+r)
+	}
+	if d := dashboardURL(a, now); d != "" {
+		lines = append(lines, " 📊 Dashboard: "+d)
+	}
+
+	lines = append(lines, "", " Labels:")
+	for _, k := range sortedKeys(a.Labels) {
+		lines = append(lines, wrapCells("   "+k+"="+a.Labels[k], w)...)
+	}
+	var annotations []string
+	for _, k := range sortedKeys(a.Annotations) {
+		if k != "summary" && k != "description" {
+			annotations = append(annotations, k)
+		}
+	}
+	if len(annotations) > 0 {
+		lines = append(lines, "", " Annotations:")
+		for _, k := range annotations {
+			lines = append(lines, wrapCells("   "+k+": "+strings.TrimSpace(a.Annotations[k]), w)...)
+		}
+	}
+
+	if t, _, _ := u.selected(); u.diagKey == t.Name+"|"+a.Key() {
+		lines = append(lines, "")
+		lines = append(lines, u.diagOut...)
+	}
+	return lines
+}
+
+var tuiHelp = []string{
+	" ↑/k ↓/j       move in the focused pane (PgUp/PgDn, g/G jump)",
+	" ←/h →/l Tab   switch between clusters, alerts and details",
+	" r             scan all clusters again",
+	" /             filter alerts by matchers, e.g. severity=\"critical\",namespace=~\"kube-.*\"",
+	" s             silence the selected alert (exact label match)",
+	" d             run the alert's diagnostic rules into the detail pane",
+	" Esc           close help or diagnosis output",
+	" q             quit",
+}
+
+// scrollStart returns the first row to show so the cursor stays visible
+func scrollStart(cursor, n, h int) int {
+	if h <= 0 || n <= h {
+		return 0
+	}
+	return max(0, min(cursor-h/2, n-h))
+}
+
+func padLines(lines []string, w, h int) []string {
+	for len(lines) < h {
+		lines = append(lines, strings.Repeat(" ", w))
+	}
+	return lines[:h]
+}
+
+// --- Text Width ---
+
+// runeCells approximates how many terminal columns a rune takes: emoji and
+// East Asian wide characters take two, combining marks none
+func runeCells(r rune) int {
+	switch {
+	case r == 0xfe0f || r == 0x200d || unicode.Is(unicode.Mn, r):
+		return 0
+	case r >= 0x1f000,
+		r >= 0x2600 && r <= 0x27bf,
+		r >= 0x1100 && r <= 0x115f,
+		r >= 0x2e80 && r <= 0xa4cf,
+		r >= 0xac00 && r <= 0xd7a3,
+		r >= 0xf900 && r <= 0xfaff,
+		r >= 0xff00 && r <= 0xff60:
+		return 2
+	}
+	return 1
+}
+
+func cellWidth(s string) int {
+	n := 0
+	for _, r := range s {
+		n += runeCells(r)
+	}
+	return n
+}
+
+// cleanText makes text safe to draw: tabs become spaces and other control
+// characters (including escape sequences from command output) are dropped
+func cleanText(s string) string {
+	s = strings.ReplaceAll(s, "\t", "    ")
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// fitCells pads or truncates s to exactly w columns
+func fitCells(s string, w int) string {
+	if w <= 0 {
+		return ""
+	}
+	s = cleanText(s)
+	n := cellWidth(s)
+	if n <= w {
+		return s + strings.Repeat(" ", w-n)
+	}
+
+	var b strings.Builder
+	n = 0
+	for _, r := range s {
+		c := runeCells(r)
+		if n+c > w-1 {
+			break
+		}
+		b.WriteRune(r)
+		n += c
+	}
+	b.WriteString("…")
+	return b.String() + strings.Repeat(" ", w-n-1)
+}
+
+// wrapCells breaks s into lines of at most w columns at spaces, continuing
+// with the indentation of the first line
+func wrapCells(s string, w int) []string {
+	s = cleanText(s)
+	indent := s[:len(s)-len(strings.TrimLeft(s, " "))]
+	var lines []string
+	line, n := "", 0
+	for _, word := range strings.Fields(s) {
+		c := cellWidth(word)
+		switch {
+		case line == "":
+			line, n = indent+word, len(indent)+c
+		case n+1+c <= w:
+			line, n = line+" "+word, n+1+c
+		default:
+			lines = append(lines, line)
+			line, n = indent+"  "+word, len(indent)+2+c
+		}
+	}
+	return append(lines, line)
+}
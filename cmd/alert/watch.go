@@ -0,0 +1,313 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"god/cmd/config"
+	"os"
+	"os/exec"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxWatchEvents is how many recent changes the full-screen view keeps
+const maxWatchEvents = 20
+
+// execHookTimeout bounds how long a single --exec invocation may run
+const execHookTimeout = 30 * time.Second
+
+// WatchEvent describes a single change between two polls. It is also the
+// JSON document passed on stdin to the --exec hook.
+type WatchEvent struct {
+	Type     string    `json:"type"` // firing, resolved or changed
+	Time     time.Time `json:"time"`
+	Target   string    `json:"target"`
+	Alert    Alert     `json:"alert"`
+	Previous *Alert    `json:"previous,omitempty"`
+}
+
+// watchFlags holds the flags of god alert watch
+type watchFlags struct {
+	*flag.FlagSet
+	targets    *targetFlags
+	endpoints  *endpointFlags
+	interval   *time.Duration
+	fullScreen *bool
+	hook       *string
+	noColor    *bool
+}
+
+func newWatchFlags() *watchFlags {
+	f := &watchFlags{FlagSet: flag.NewFlagSet("watch", flag.ExitOnError)}
+	f.targets = addTargetFlags(f.FlagSet)
+	f.endpoints = addEndpointFlags(f.FlagSet, true)
+	f.interval = f.Duration("interval", 30*time.Second, "Time between polls")
+	f.fullScreen = f.Bool("clear", false, "Redraw a full-screen view on every poll (TTY only)")
+	f.hook = f.String("exec", "", "Shell command run for every change, with the event as JSON on stdin")
+	f.noColor = addDisplayFlags(f.FlagSet)
+	return f
+}
+
+func runWatch(args []string) {
+	f := newWatchFlags()
+	tf, ef, interval, fullScreen, hook, noColor := f.targets, f.endpoints, f.interval, f.fullScreen, f.hook, f.noColor
+	config.Apply(f.FlagSet, "alert")
+	f.Parse(args)
+	configureEndpoints(ef)
+	configureDisplay(*noColor)
+
+	if *interval <= 0 {
+		fmt.Println("❌ Error: --interval must be positive")
+		os.Exit(1)
+	}
+
+	var targets []Target
+	if tf.empty() {
+		targets = append(targets, defaultTarget())
+	} else {
+		targets = tf.targets()
+		if len(targets) == 0 {
+			return
+		}
+	}
+	// Runs until interrupted, which releases the targets
+	defer releaseOnSignal(targets)()
+
+	if *fullScreen && !isTerminal(os.Stdout) {
+		fmt.Println("⚠️  --clear ignored: stdout is not a terminal")
+		*fullScreen = false
+	}
+
+	// state holds the last known alerts, keyed by target and then fingerprint
+	state := make(map[string]map[string]Alert)
+	var recent []WatchEvent
+
+	fmt.Printf("👀 Watching %d target(s) every %s (Ctrl+C to stop)\n", len(targets), *interval)
+
+	for {
+		now := time.Now()
+		var events []WatchEvent
+		var failures []string
+		// baseline holds the targets polled successfully for the first time
+		var baseline []Target
+
+		for _, t := range targets {
+			if err := t.Connect(); err != nil {
+				failures = append(failures, fmt.Sprintf("%s: login failed: %v", t.Name, err))
+				continue
+			}
+
+			alerts, err := FetchAlerts(t)
+			if err != nil {
+				// Keep the previous state so a flaky poll doesn't look like a mass resolve
+				failures = append(failures, fmt.Sprintf("%s: %v", t.Name, err))
+				continue
+			}
+
+			current := alertMap(alerts)
+			if _, seen := state[t.Name]; seen {
+				events = append(events, diffAlerts(t.Name, state[t.Name], current, now)...)
+			} else {
+				baseline = append(baseline, t)
+			}
+			state[t.Name] = current
+		}
+		restoreKubeContext()
+
+		if *fullScreen {
+			recent = append(recent, events...)
+			if len(recent) > maxWatchEvents {
+				recent = recent[len(recent)-maxWatchEvents:]
+			}
+			renderWatchScreen(targets, state, recent, failures, *interval, now)
+		} else {
+			if len(baseline) > 0 {
+				printWatchBaseline(baseline, state)
+			}
+			for _, f := range failures {
+				fmt.Printf("⚠️  [%s] %s\n", now.Format("15:04:05"), f)
+			}
+			for _, e := range events {
+				printWatchEvent(e)
+			}
+		}
+
+		if *hook != "" {
+			for _, e := range events {
+				runWatchHook(*hook, e)
+			}
+		}
+
+		time.Sleep(*interval)
+	}
+}
+
+// diffAlerts compares two snapshots of the same target and returns the
+// newly firing, resolved and changed alerts in a stable order
+func diffAlerts(target string, prev, curr map[string]Alert, now time.Time) []WatchEvent {
+	var events []WatchEvent
+
+	for key, a := range curr {
+		old, ok := prev[key]
+		if !ok {
+			events = append(events, WatchEvent{Type: "firing", Time: now, Target: target, Alert: a})
+			continue
+		}
+		if old.StartsAt != a.StartsAt || !reflect.DeepEqual(old.Annotations, a.Annotations) {
+			o := old
+			events = append(events, WatchEvent{Type: "changed", Time: now, Target: target, Alert: a, Previous: &o})
+		}
+	}
+	for key, a := range prev {
+		if _, ok := curr[key]; !ok {
+			events = append(events, WatchEvent{Type: "resolved", Time: now, Target: target, Alert: a})
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].Type != events[j].Type {
+			return events[i].Type < events[j].Type
+		}
+		return events[i].Alert.Labels["alertname"] < events[j].Alert.Labels["alertname"]
+	})
+	return events
+}
+
+// printWatchBaseline shows the alerts of the targets polled for the first time
+func printWatchBaseline(targets []Target, state map[string]map[string]Alert) {
+	for _, t := range targets {
+		current, ok := state[t.Name]
+		if !ok {
+			continue
+		}
+		fmt.Printf("\n🌐 %s\n", t.Name)
+		printAlerts(sortedAlerts(current), alertView{})
+	}
+	fmt.Println("--- Changes ---")
+}
+
+func printWatchEvent(e WatchEvent) {
+	icon := map[string]string{"firing": "🔴", "resolved": "✅", "changed": "🔁"}[e.Type]
+	fmt.Printf("%s [%s] %-8s %-35s %s -> %s\n", icon, e.Time.Format("15:04:05"), e.Type,
+		e.Alert.Labels["alertname"], e.Target, alertTarget(e.Alert))
+
+	if e.Previous != nil {
+		for _, c := range describeChanges(*e.Previous, e.Alert) {
+			fmt.Printf("      %s\n", c)
+		}
+	}
+}
+
+// describeChanges lists what differs between two versions of an alert
+func describeChanges(old, curr Alert) []string {
+	var changes []string
+	if old.StartsAt != curr.StartsAt {
+		changes = append(changes, fmt.Sprintf("startsAt: %s -> %s", old.StartsAt, curr.StartsAt))
+	}
+
+	keys := make(map[string]bool)
+	for k := range old.Annotations {
+		keys[k] = true
+	}
+	for k := range curr.Annotations {
+		keys[k] = true
+	}
+	names := make([]string, 0, len(keys))
+	for k := range keys {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	for _, k := range names {
+		if old.Annotations[k] != curr.Annotations[k] {
+			changes = append(changes, fmt.Sprintf("%s: %q -> %q", k, old.Annotations[k], curr.Annotations[k]))
+		}
+	}
+	return changes
+}
+
+func renderWatchScreen(targets []Target, state map[string]map[string]Alert, recent []WatchEvent, failures []string, interval time.Duration, now time.Time) {
+	// Move the cursor home and clear the screen
+	fmt.Print("\033[H\033[2J")
+	fmt.Printf("👀 god alert watch — every %s — last poll %s\n", interval, now.Format("15:04:05"))
+
+	for _, t := range targets {
+		fmt.Printf("\n🌐 %s\n", t.Name)
+		printAlerts(sortedAlerts(state[t.Name]), alertView{})
+	}
+
+	for _, f := range failures {
+		fmt.Printf("⚠️  %s\n", f)
+	}
+
+	fmt.Println("--- Recent changes ---")
+	if len(recent) == 0 {
+		fmt.Println("   (none yet)")
+	}
+	for _, e := range recent {
+		printWatchEvent(e)
+	}
+}
+
+// runWatchHook pipes the event as JSON into the user's shell command
+func runWatchHook(hook string, e WatchEvent) {
+	payload, err := json.Marshal(e)
+	if err != nil {
+		fmt.Printf("⚠️  Could not encode event for --exec: %v\n", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), execHookTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", hook)
+	cmd.Stdin = strings.NewReader(string(payload))
+	cmd.Env = append(os.Environ(), "GOD_EVENT="+e.Type, "GOD_TARGET="+e.Target)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if _, err := audited(Target{}, cmd, hook, runOnly); err != nil {
+		fmt.Printf("⚠️  --exec hook failed: %v\n", err)
+	}
+}
+
+// sortedAlerts flattens a fingerprint map back into a stable slice, most
+// severe first
+func sortedAlerts(m map[string]Alert) []Alert {
+	alerts := make([]Alert, 0, len(m))
+	for _, a := range m {
+		alerts = append(alerts, a)
+	}
+	sort.Slice(alerts, func(i, j int) bool {
+		ni, nj := alerts[i].Labels["alertname"], alerts[j].Labels["alertname"]
+		if ni != nj {
+			return ni < nj
+		}
+		return alerts[i].Key() < alerts[j].Key()
+	})
+	sortAlerts(alerts)
+	return alerts
+}
+
+// alertTarget returns the "namespace/pod" style location shown in listings
+func alertTarget(alert Alert) string {
+	ns := alert.Labels["namespace"]
+	target := "cluster-wide"
+	if pod, ok := alert.Labels["pod"]; ok {
+		target = pod
+	} else if instance, ok := alert.Labels["instance"]; ok {
+		target = instance
+	}
+	if ns == "" {
+		ns = "global"
+	}
+	return ns + "/" + target
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,46 @@
+// cmd/audit/handler.go
+package audit
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// Commands declares the flags of each audit command, for god config
+var Commands = map[string]func() *flag.FlagSet{
+	"show": func() *flag.FlagSet { return newShowFlags().FlagSet },
+}
+
+// Handle processes the 'god audit ...' commands
+func Handle(args []string) {
+	if len(args) < 1 {
+		printHelp()
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "show":
+		runShow(args[1:])
+	case "help":
+		printHelp()
+	default:
+		fmt.Printf("Unknown audit command: %s\n", args[0])
+		printHelp()
+		os.Exit(1)
+	}
+}
+
+func printHelp() {
+	fmt.Println("Usage: god audit <command> [flags]")
+	fmt.Println("\nCommands:")
+	fmt.Println("  show     Show commands god ran against clusters and servers")
+	fmt.Println("\nFlags (show):")
+	fmt.Println("  --since <dur>      Only entries newer than this, e.g. 2h, 1d, 1w (default 1d)")
+	fmt.Println("  --target <glob>    Only entries for matching targets")
+	fmt.Println("  --user <name>      Only entries of this user")
+	fmt.Println("  --failed           Only commands that failed")
+	fmt.Println("  --json             Print the matching entries as JSON lines")
+	fmt.Println("  --log <file>       Audit log to read (default ~/.local/state/god/audit.jsonl)")
+	fmt.Println("\nThe log is written on every run; set GOD_AUDIT_LOG to move it.")
+}
@@ -0,0 +1,138 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"god/cmd/alert"
+	"god/cmd/config"
+	"os"
+	"path"
+	"text/tabwriter"
+	"time"
+)
+
+// showFlags holds the flags of god audit show
+type showFlags struct {
+	*flag.FlagSet
+	since      *string
+	targetGlob *string
+	userName   *string
+	failed     *bool
+	asJSON     *bool
+	logFile    *string
+}
+
+func newShowFlags() *showFlags {
+	f := &showFlags{FlagSet: flag.NewFlagSet("show", flag.ExitOnError)}
+	f.since = f.String("since", "1d", "Only entries newer than this (e.g. 2h, 1d, 1w)")
+	f.targetGlob = f.String("target", "", "Only entries for targets matching this glob")
+	f.userName = f.String("user", "", "Only entries of this user")
+	f.failed = f.Bool("failed", false, "Only commands that failed")
+	f.asJSON = f.Bool("json", false, "Print matching entries as JSON lines")
+	f.logFile = f.String("log", alert.AuditPath(), "Audit log to read")
+	return f
+}
+
+func runShow(args []string) {
+	f := newShowFlags()
+	since, targetGlob, userName, failed, asJSON, logFile := f.since, f.targetGlob, f.userName, f.failed, f.asJSON, f.logFile
+	config.Apply(f.FlagSet, "audit")
+	f.Parse(args)
+
+	window, err := alert.ParseLongDuration(*since)
+	if err != nil {
+		fmt.Printf("❌ Error: --since: %v\n", err)
+		os.Exit(1)
+	}
+	if _, err := path.Match(*targetGlob, ""); err != nil {
+		fmt.Printf("❌ Error: invalid --target pattern '%s': %v\n", *targetGlob, err)
+		os.Exit(1)
+	}
+	cutoff := time.Now().Add(-window)
+
+	in, err := os.Open(*logFile)
+	if os.IsNotExist(err) {
+		fmt.Println("✅ The audit log is empty.")
+		return
+	}
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	defer in.Close()
+
+	var entries []alert.AuditEntry
+	bad := 0
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var e alert.AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			bad++
+			continue
+		}
+		if e.Time.Before(cutoff) {
+			continue
+		}
+		if *targetGlob != "" {
+			if ok, _ := path.Match(*targetGlob, e.Target); !ok {
+				continue
+			}
+		}
+		if *userName != "" && e.User != *userName {
+			continue
+		}
+		if *failed && e.ExitCode == 0 && e.Error == "" {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Printf("❌ Error reading %s: %v\n", *logFile, err)
+		os.Exit(1)
+	}
+	if bad > 0 {
+		fmt.Fprintf(os.Stderr, "⚠️  Skipped %d unreadable lines in %s\n", bad, *logFile)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		for _, e := range entries {
+			enc.Encode(e)
+		}
+		return
+	}
+
+	if len(entries) == 0 {
+		fmt.Printf("✅ No commands recorded since %s.\n", cutoff.Format("2006-01-02 15:04"))
+		return
+	}
+
+	fmt.Printf("📜 %d commands since %s:\n\n", len(entries), cutoff.Format("2006-01-02 15:04"))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tUSER\tTARGET\tEXIT\tDURATION\tCOMMAND")
+	for _, e := range entries {
+		target := e.Target
+		if target == "" {
+			target = "(" + e.Kind + ")"
+		}
+		exit := fmt.Sprint(e.ExitCode)
+		if e.HTTPStatus != 0 {
+			exit = fmt.Sprintf("HTTP %d", e.HTTPStatus)
+		}
+		if e.ExitCode != 0 || e.Error != "" {
+			exit = "❌ " + exit
+		}
+		command := e.Command
+		if e.Shell != "" {
+			command = e.Shell
+		}
+		duration := (time.Duration(e.DurationMs) * time.Millisecond).String()
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", e.Time.Local().Format("2006-01-02 15:04:05"),
+			e.User, target, exit, duration, alert.Truncate(command, 100))
+	}
+	w.Flush()
+}
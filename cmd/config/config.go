@@ -0,0 +1,374 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// File is the god config file. Besides the profiles it holds a section per
+// module (e.g. alert:) that the module reads itself.
+type File struct {
+	// Profile is used when neither --profile nor GOD_PROFILE is given
+	Profile string `yaml:"profile"`
+	// Profiles map a module to its flag defaults. A nested map holds the
+	// defaults of one command, e.g. alert: {scan: {group-by: cluster}}.
+	Profiles map[string]map[string]interface{} `yaml:"profiles"`
+}
+
+// Module is a god module whose flags profiles and environment can set
+type Module struct {
+	Name string
+	// Commands declare the flags of each command, keyed by the command
+	// name of its flag set, e.g. "rules lint"
+	Commands map[string]func() *flag.FlagSet
+	// Validate checks the module's own section of the config file
+	Validate func(section *yaml.Node) []string
+}
+
+// setting is where the value of one flag comes from
+type setting struct {
+	Values []string
+	// Source is the env var or "profile <name>"; Key is the config key
+	Source string
+	Key    string
+}
+
+var modules []Module
+
+// global holds --config and --profile and the loaded file
+var global struct {
+	path, pathSource       string
+	profile, profileSource string
+	loaded                 bool
+	file                   *File
+	err                    error
+}
+
+// Register makes a module's flags configurable
+func Register(m Module) {
+	modules = append(modules, m)
+}
+
+// Init removes the global --config and --profile flags, which may appear
+// anywhere on the command line, and returns the remaining arguments
+func Init(args []string) []string {
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			rest = append(rest, args[i:]...)
+			break
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || (name != "config" && name != "profile") {
+			rest = append(rest, arg)
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				fmt.Printf("❌ Error: --%s needs a value\n", name)
+				os.Exit(1)
+			}
+			i++
+			value = args[i]
+		}
+		if name == "config" {
+			global.path, global.pathSource = value, "--config"
+		} else {
+			global.profile, global.profileSource = value, "--profile"
+		}
+	}
+	return rest
+}
+
+// Path returns the config file: --config, then $GOD_CONFIG, then
+// $XDG_CONFIG_HOME/god/config.yaml (default ~/.config/god/config.yaml)
+func Path() string {
+	path, _ := pathAndSource()
+	return path
+}
+
+func pathAndSource() (string, string) {
+	if global.path != "" {
+		return global.path, global.pathSource
+	}
+	if path := os.Getenv("GOD_CONFIG"); path != "" {
+		return path, "GOD_CONFIG"
+	}
+	base := os.Getenv("XDG_CONFIG_HOME")
+	if base == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", "default"
+		}
+		base = filepath.Join(home, ".config")
+	}
+	return filepath.Join(base, "god", "config.yaml"), "default"
+}
+
+// Load reads the config file once. A missing file yields an empty config.
+func Load() (*File, error) {
+	if global.loaded {
+		return global.file, global.err
+	}
+	global.loaded = true
+	global.file = &File{}
+
+	path := Path()
+	if path == "" {
+		return global.file, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) && global.path == "" && os.Getenv("GOD_CONFIG") == "" {
+		return global.file, nil
+	}
+	if err != nil {
+		global.err = err
+		return global.file, err
+	}
+	if err := yaml.Unmarshal(data, global.file); err != nil {
+		global.err = fmt.Errorf("%s: %v", path, err)
+	}
+	return global.file, global.err
+}
+
+// profileName returns the selected profile and what selected it: --profile,
+// then $GOD_PROFILE, then the profile key of the config file
+func profileName(f *File) (string, string) {
+	if global.profile != "" {
+		return global.profile, global.profileSource
+	}
+	if name := os.Getenv("GOD_PROFILE"); name != "" {
+		return name, "GOD_PROFILE"
+	}
+	if f != nil && f.Profile != "" {
+		return f.Profile, "profile key"
+	}
+	return "", ""
+}
+
+// selectedProfile returns the name and settings of the selected profile
+func selectedProfile() (string, map[string]interface{}, error) {
+	f, err := Load()
+	if err != nil {
+		return "", nil, err
+	}
+	name, _ := profileName(f)
+	if name == "" {
+		return "", nil, nil
+	}
+	p, ok := f.Profiles[name]
+	if !ok {
+		return "", nil, fmt.Errorf("unknown profile '%s' in %s (profiles: %s)", name, Path(), profileList(f))
+	}
+	return name, p, nil
+}
+
+func profileList(f *File) string {
+	if len(f.Profiles) == 0 {
+		return "none"
+	}
+	var names []string
+	for name := range f.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// --- Flags ---
+
+// Apply sets the flags of a command that weren't given on the command line
+// from the environment and the selected profile. It runs before Parse, so
+// the precedence is flag > env > profile > default. The values are set
+// without marking the flags as given, so FlagSet.Visit only sees the command
+// line and Preset tells where the others came from.
+func Apply(fs *flag.FlagSet, module string) {
+	command := strings.Fields(fs.Name())
+	var failed error
+	fs.VisitAll(func(f *flag.Flag) {
+		s, err := resolve(module, command, f.Name)
+		if err != nil || s == nil {
+			if failed == nil {
+				failed = err
+			}
+			return
+		}
+		for _, v := range s.Values {
+			if err := f.Value.Set(v); err != nil && failed == nil {
+				failed = fmt.Errorf("%s (%s): invalid value '%s' for --%s: %v", s.Key, s.Source, v, f.Name, err)
+			}
+		}
+		if presets[fs] == nil {
+			presets[fs] = map[string]string{}
+		}
+		presets[fs][f.Name] = s.Source
+		// A repeatable flag appends on Set, so the command line must replace
+		// the preset values instead of adding to them
+		if r, ok := f.Value.(resetter); ok {
+			f.Value = &presetValue{resetter: r}
+		}
+	})
+	if failed != nil {
+		fmt.Printf("❌ Error: %v\n", failed)
+		os.Exit(1)
+	}
+}
+
+// presets maps the flags Apply set to their env var or profile
+var presets = map[*flag.FlagSet]map[string]string{}
+
+// Preset returns the env var or profile a flag was set from by Apply, and
+// false when it was given on the command line or kept its default
+func Preset(fs *flag.FlagSet, name string) (string, bool) {
+	source, ok := presets[fs][name]
+	if !ok {
+		return "", false
+	}
+	given := false
+	fs.Visit(func(f *flag.Flag) {
+		given = given || f.Name == name
+	})
+	if given {
+		return "", false
+	}
+	return source, true
+}
+
+// resetter is a repeatable flag that can drop its values
+type resetter interface {
+	flag.Value
+	Reset()
+}
+
+// presetValue wraps a repeatable flag set by Apply; the first Set from the
+// command line resets it
+type presetValue struct {
+	resetter
+	given bool
+}
+
+func (p *presetValue) Set(value string) error {
+	if !p.given {
+		p.given = true
+		p.Reset()
+	}
+	return p.resetter.Set(value)
+}
+
+// resolve returns where a flag of a command gets its value from, or nil when
+// it keeps its default. The most specific env var or profile key wins:
+// GOD_ALERT_SCAN_FILTER over GOD_ALERT_FILTER, alert.scan.filter over
+// alert.filter.
+func resolve(module string, command []string, name string) (*setting, error) {
+	for i := len(command); i >= 0; i-- {
+		env := envName(module, command[:i], name)
+		if v, ok := os.LookupEnv(env); ok {
+			return &setting{Values: []string{v}, Source: env, Key: key(module, command[:i], name)}, nil
+		}
+	}
+
+	profile, p, err := selectedProfile()
+	if err != nil || p == nil {
+		return nil, err
+	}
+	sections := []interface{}{p[module]}
+	for _, c := range command {
+		section, _ := sections[len(sections)-1].(map[string]interface{})
+		sections = append(sections, section[c])
+	}
+	for i := len(sections) - 1; i >= 0; i-- {
+		section, _ := sections[i].(map[string]interface{})
+		v, ok := section[name]
+		if !ok || v == nil {
+			continue
+		}
+		if _, nested := v.(map[string]interface{}); nested {
+			continue
+		}
+		return &setting{Values: values(v), Source: "profile " + profile, Key: key(module, command[:i], name)}, nil
+	}
+	return nil, nil
+}
+
+// values turns a profile value into flag values; a list sets a repeatable
+// flag once per item
+func values(v interface{}) []string {
+	list, ok := v.([]interface{})
+	if !ok {
+		list = []interface{}{v}
+	}
+	var out []string
+	for _, item := range list {
+		s := fmt.Sprint(item)
+		if strings.HasPrefix(s, "~/") {
+			if home, err := os.UserHomeDir(); err == nil {
+				s = filepath.Join(home, s[2:])
+			}
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// envName is the variable that sets a flag, e.g. GOD_ALERT_SCAN_GROUP_BY
+func envName(module string, command []string, name string) string {
+	parts := append([]string{"god", module}, command...)
+	parts = append(parts, name)
+	return strings.ToUpper(strings.ReplaceAll(strings.Join(parts, "_"), "-", "_"))
+}
+
+// key is the dotted name of a flag in a profile, e.g. alert.scan.group-by
+func key(module string, command []string, name string) string {
+	return strings.Join(append(append([]string{module}, command...), name), ".")
+}
+
+// --- Describe ---
+
+// command is the flag set of one command of a module
+type command struct {
+	Module *Module
+	Path   []string
+	Flags  *flag.FlagSet
+}
+
+// describe declares the flags of one command
+func describe(m *Module, name string) *command {
+	return &command{Module: m, Path: strings.Fields(name), Flags: m.Commands[name]()}
+}
+
+// commandNames returns the commands of a module in a stable order
+func commandNames(m *Module) []string {
+	var names []string
+	for name := range m.Commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// describeAll declares the flags of every command of every module
+func describeAll() []*command {
+	var commands []*command
+	for i := range modules {
+		for _, name := range commandNames(&modules[i]) {
+			commands = append(commands, describe(&modules[i], name))
+		}
+	}
+	return commands
+}
+
+func findModule(name string) *Module {
+	for i := range modules {
+		if modules[i].Name == name {
+			return &modules[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const testConfig = `profile: prod
+profiles:
+  prod:
+    alert:
+      n: monitoring-prod
+      svc: svc/am-prod
+      port: 9094
+      insecure: true
+      scan:
+        silence: [a, b]
+`
+
+// listFlag is a repeatable flag such as --silence
+type listFlag []string
+
+func (l *listFlag) String() string     { return strings.Join(*l, ",") }
+func (l *listFlag) Set(v string) error { *l = append(*l, v); return nil }
+func (l *listFlag) Reset()             { *l = nil }
+
+type testFlags struct {
+	fs                 *flag.FlagSet
+	n, svc, port, sort *string
+	insecure           *bool
+	silence            *listFlag
+}
+
+func newTestFlags() *testFlags {
+	f := &testFlags{fs: flag.NewFlagSet("scan", flag.ContinueOnError), silence: &listFlag{}}
+	f.n = f.fs.String("n", "", "")
+	f.svc = f.fs.String("svc", "", "")
+	f.port = f.fs.String("port", "", "")
+	f.sort = f.fs.String("sort", "severity", "")
+	f.insecure = f.fs.Bool("insecure", false, "")
+	f.fs.Var(f.silence, "silence", "")
+	return f
+}
+
+// setup points the package at a fresh config file with the prod profile
+func setup(t *testing.T) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(testConfig), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOD_CONFIG", path)
+	t.Setenv("GOD_PROFILE", "")
+	global.loaded, global.file, global.err = false, nil, nil
+	global.path, global.profile = "", ""
+}
+
+func TestApplyPrecedence(t *testing.T) {
+	setup(t)
+	t.Setenv("GOD_ALERT_SCAN_PORT", "9095")
+
+	f := newTestFlags()
+	Apply(f.fs, "alert")
+	if err := f.fs.Parse([]string{"--svc", "svc/am-cli", "--silence", "c", "--insecure"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		flag, got, want string
+		// source is where Preset says the value came from, "" when it
+		// was given on the command line or not set
+		source string
+	}{
+		{"n", *f.n, "monitoring-prod", "profile prod"},
+		{"svc", *f.svc, "svc/am-cli", ""},
+		{"port", *f.port, "9095", "GOD_ALERT_SCAN_PORT"},
+		{"sort", *f.sort, "severity", ""},
+		{"silence", f.silence.String(), "c", ""},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("--%s = %q, want %q", tt.flag, tt.got, tt.want)
+		}
+		source, ok := Preset(f.fs, tt.flag)
+		if source != tt.source || ok != (tt.source != "") {
+			t.Errorf("Preset(%s) = %q, %v, want %q", tt.flag, source, ok, tt.source)
+		}
+	}
+	if !*f.insecure {
+		t.Errorf("--insecure without a value did not set a preset bool flag")
+	}
+}
+
+func TestApplyRepeatableFromProfile(t *testing.T) {
+	setup(t)
+
+	f := newTestFlags()
+	Apply(f.fs, "alert")
+	if err := f.fs.Parse(nil); err != nil {
+		t.Fatal(err)
+	}
+	if got := f.silence.String(); got != "a,b" {
+		t.Errorf("--silence = %q, want the profile list a,b", got)
+	}
+	if source, ok := Preset(f.fs, "silence"); !ok || source != "profile prod" {
+		t.Errorf("Preset(silence) = %q, %v, want profile prod", source, ok)
+	}
+}
@@ -0,0 +1,324 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Handle processes the 'god config ...' commands
+func Handle(args []string) {
+	if len(args) < 1 {
+		printHelp()
+		os.Exit(1)
+	}
+
+	switch args[0] {
+	case "show":
+		runShow(args[1:])
+	case "get":
+		runGet(args[1:])
+	case "set":
+		runSet(args[1:])
+	case "validate":
+		runValidate(args[1:])
+	case "help":
+		printHelp()
+	default:
+		fmt.Printf("Unknown config command: %s\n", args[0])
+		printHelp()
+		os.Exit(1)
+	}
+}
+
+func printHelp() {
+	fmt.Println("Usage: god config <command> [flags]")
+	fmt.Println("\nCommands:")
+	fmt.Println("  show [module [command]]  Config file, profile and every flag default it sets;")
+	fmt.Println("                           with a command, all of its flags and where each comes from")
+	fmt.Println("  get <key>                Effective value of a flag, e.g. alert.filter or alert.scan.group-by,")
+	fmt.Println("                           or of a config file key, e.g. alert.teleport.cache_ttl")
+	fmt.Println("  set <key> <value>        Write a flag default to the selected profile, or a file key")
+	fmt.Println("  validate                 Check the config file, the profiles and GOD_* variables")
+	fmt.Println("\nGlobal flags (any module):")
+	fmt.Println("  --config <file>    Config file (also GOD_CONFIG; default ~/.config/god/config.yaml)")
+	fmt.Println("  --profile <name>   Profile of flag defaults (also GOD_PROFILE; default: profile key of the file)")
+	fmt.Println("\nPrecedence: flag > env > profile > default. GOD_<MODULE>_<FLAG> sets a flag of every command")
+	fmt.Println("of the module, GOD_<MODULE>_<COMMAND>_<FLAG> one command, e.g. GOD_ALERT_SVC, GOD_GIT_PULL_PATH.")
+}
+
+// --- Show ---
+
+func runShow(args []string) {
+	f, err := Load()
+	path, pathSource := pathAndSource()
+	fmt.Printf("📄 Config:  %s (%s)\n", path, pathSource)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	name, source := profileName(f)
+	if name == "" {
+		fmt.Printf("👤 Profile: none (profiles: %s)\n", profileList(f))
+	} else {
+		fmt.Printf("👤 Profile: %s (%s; profiles: %s)\n", name, source, profileList(f))
+	}
+	if _, _, err := selectedProfile(); err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(args) > 0 {
+		showCommand(args)
+		return
+	}
+
+	// One line per env var or profile key, with the commands it reaches
+	type entry struct {
+		s        *setting
+		commands []string
+	}
+	entries := make(map[string]*entry)
+	for _, c := range describeAll() {
+		c.Flags.VisitAll(func(fl *flag.Flag) {
+			s, _ := resolve(c.Module.Name, c.Path, fl.Name)
+			if s == nil {
+				return
+			}
+			id := s.Key + "|" + s.Source
+			if entries[id] == nil {
+				entries[id] = &entry{s: s}
+			}
+			entries[id].commands = append(entries[id].commands, strings.Join(append([]string{c.Module.Name}, c.Path...), " "))
+		})
+	}
+	if len(entries) == 0 {
+		fmt.Println("\nNo flag defaults are set; every flag uses its built-in default.")
+		return
+	}
+
+	var ids []string
+	for id := range entries {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	fmt.Println()
+	for _, id := range ids {
+		e := entries[id]
+		fmt.Printf("  %-28s = %-24s %s\n", e.s.Key, strings.Join(e.s.Values, ","), e.s.Source)
+		fmt.Printf("  %-28s   used by %s\n", "", strings.Join(e.commands, ", "))
+	}
+}
+
+// showCommand prints every flag of one command with its effective value
+func showCommand(args []string) {
+	m := findModule(args[0])
+	if m == nil {
+		fmt.Printf("❌ Error: Unknown module '%s'\n", args[0])
+		os.Exit(1)
+	}
+
+	var commands []*command
+	for _, name := range commandNames(m) {
+		if len(args) == 1 || name == strings.Join(args[1:], " ") {
+			commands = append(commands, describe(m, name))
+		}
+	}
+	if len(commands) == 0 {
+		fmt.Printf("❌ Error: Unknown %s command '%s'\n", m.Name, strings.Join(args[1:], " "))
+		os.Exit(1)
+	}
+
+	for _, c := range commands {
+		fmt.Printf("\n⚙️  %s %s\n", m.Name, strings.Join(c.Path, " "))
+		c.Flags.VisitAll(func(fl *flag.Flag) {
+			value, source := fl.DefValue, "default"
+			if s, _ := resolve(m.Name, c.Path, fl.Name); s != nil {
+				value, source = strings.Join(s.Values, ","), s.Source+" ("+s.Key+")"
+			}
+			fmt.Printf("  --%-22s %-28s %s\n", fl.Name, value, source)
+		})
+	}
+}
+
+// --- Get ---
+
+func runGet(args []string) {
+	if len(args) != 1 {
+		fmt.Println("Usage: god config get <key>, e.g. god config get alert.filter")
+		os.Exit(1)
+	}
+	key := args[0]
+
+	if key == "profile" {
+		f, err := Load()
+		if err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+			os.Exit(1)
+		}
+		name, _ := profileName(f)
+		fmt.Println(name)
+		return
+	}
+
+	if fk, ok := parseFlagKey(key); ok {
+		s, err := resolve(fk.module.Name, fk.command, fk.name)
+		if err != nil {
+			fmt.Printf("❌ Error: %v\n", err)
+			os.Exit(1)
+		}
+		if s == nil {
+			fmt.Println(fk.flag.DefValue)
+			return
+		}
+		fmt.Println(strings.Join(s.Values, ","))
+		return
+	}
+
+	root, err := readNode()
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	out, err := lookupNode(root, strings.Split(key, "."))
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println(out)
+}
+
+// flagKey is a dotted key naming a flag, e.g. alert.scan.group-by
+type flagKey struct {
+	module  *Module
+	command []string
+	name    string
+	// flag is the flag in the first command that has it
+	flag *flag.Flag
+}
+
+// parseFlagKey resolves <module>[.<command>...].<flag> against the flags
+// of the module's commands
+func parseFlagKey(key string) (*flagKey, bool) {
+	parts := strings.Split(key, ".")
+	if len(parts) < 2 {
+		return nil, false
+	}
+	m := findModule(parts[0])
+	if m == nil {
+		return nil, false
+	}
+	fk := &flagKey{module: m, command: parts[1 : len(parts)-1], name: parts[len(parts)-1]}
+	for _, name := range commandNames(m) {
+		c := describe(m, name)
+		if !hasPrefix(c.Path, fk.command) {
+			continue
+		}
+		if fl := c.Flags.Lookup(fk.name); fl != nil {
+			fk.flag = fl
+			return fk, true
+		}
+	}
+	return nil, false
+}
+
+func hasPrefix(path, prefix []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if path[i] != prefix[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// --- Set ---
+
+func runSet(args []string) {
+	if len(args) != 2 {
+		fmt.Println("Usage: god config set <key> <value>, e.g. god config set alert.svc svc/alertmanager --profile prod-eu")
+		os.Exit(1)
+	}
+	key, value := args[0], args[1]
+
+	root, err := readNode()
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	path := strings.Split(key, ".")
+	if fk, ok := parseFlagKey(key); ok {
+		if err := fk.flag.Value.Set(value); err != nil {
+			fmt.Printf("❌ Error: Invalid value '%s' for --%s: %v\n", value, fk.name, err)
+			os.Exit(1)
+		}
+		f, _ := Load()
+		profile, _ := profileName(f)
+		if profile == "" {
+			fmt.Println("❌ Error: Flag defaults are kept in profiles, select one with --profile <name>")
+			os.Exit(1)
+		}
+		path = append([]string{"profiles", profile}, path...)
+	} else if key == "profile" {
+		if f, err := Load(); err == nil {
+			if _, ok := f.Profiles[value]; !ok {
+				fmt.Printf("❌ Error: Unknown profile '%s' (profiles: %s)\n", value, profileList(f))
+				os.Exit(1)
+			}
+		}
+	}
+
+	// A key of a module's own section must not add problems to it
+	m := findModule(path[0])
+	known := make(map[string]bool)
+	if m != nil && m.Validate != nil {
+		for _, p := range m.Validate(mappingValue(root, path[0])) {
+			known[p] = true
+		}
+	}
+	if err := setNode(root, path, value); err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		os.Exit(1)
+	}
+	if m != nil && m.Validate != nil {
+		for _, p := range m.Validate(mappingValue(root, path[0])) {
+			if !known[p] {
+				fmt.Printf("❌ Error: Not setting %s: %s\n", key, p)
+				os.Exit(1)
+			}
+		}
+	}
+	if err := writeNode(root); err != nil {
+		fmt.Printf("❌ Error writing %s: %v\n", Path(), err)
+		os.Exit(1)
+	}
+	fmt.Printf("✅ Set %s = %s in %s\n", strings.Join(path, "."), value, Path())
+}
+
+// --- Validate ---
+
+func runValidate(args []string) {
+	path, pathSource := pathAndSource()
+	if _, err := os.Stat(path); os.IsNotExist(err) && pathSource == "default" {
+		fmt.Printf("ℹ️  No config file at %s; every flag uses its built-in default\n", path)
+		return
+	}
+
+	problems, warnings := validate()
+	for _, w := range warnings {
+		fmt.Printf("⚠️  %s\n", w)
+	}
+	for _, p := range problems {
+		fmt.Printf("❌ %s\n", p)
+	}
+	if len(problems) > 0 {
+		fmt.Printf("\n❌ %s has %d problem(s)\n", path, len(problems))
+		os.Exit(1)
+	}
+	fmt.Printf("✅ %s is valid\n", path)
+}
@@ -0,0 +1,177 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// validate checks the config file, the selected profile and the GOD_*
+// variables. Problems make the config unusable; warnings are likely typos.
+func validate() (problems, warnings []string) {
+	root, err := readNode()
+	if err != nil {
+		return []string{err.Error()}, nil
+	}
+	f, err := Load()
+	if err != nil {
+		return []string{err.Error()}, nil
+	}
+
+	commands := describeAll()
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i].Value, root.Content[i+1]
+		switch {
+		case key == "profile":
+			if _, ok := f.Profiles[f.Profile]; !ok && f.Profile != "" {
+				problems = append(problems, fmt.Sprintf("profile: unknown profile '%s' (profiles: %s)", f.Profile, profileList(f)))
+			}
+		case key == "profiles":
+			if value.Kind != yaml.MappingNode {
+				problems = append(problems, "profiles: must be a mapping of profile names")
+				continue
+			}
+			for j := 0; j+1 < len(value.Content); j += 2 {
+				problems = append(problems, validateProfile(value.Content[j].Value, value.Content[j+1], commands)...)
+			}
+		case findModule(key) != nil:
+			if m := findModule(key); m.Validate != nil {
+				for _, p := range m.Validate(value) {
+					problems = append(problems, key+": "+p)
+				}
+			}
+		default:
+			problems = append(problems, fmt.Sprintf("%s: unknown key (expected profile, profiles or a module: %s)", key, moduleList()))
+		}
+	}
+
+	if name, source := profileName(f); name != "" && source != "profile key" {
+		if _, ok := f.Profiles[name]; !ok {
+			problems = append(problems, fmt.Sprintf("%s: unknown profile '%s' (profiles: %s)", source, name, profileList(f)))
+		}
+	}
+	return problems, append(warnings, unknownEnv(commands)...)
+}
+
+// validateProfile checks that every key of a profile is a module, a command
+// of it or a flag the commands below it have, with a value the flag accepts
+func validateProfile(name string, profile *yaml.Node, commands []*command) []string {
+	if profile.Kind != yaml.MappingNode {
+		return []string{fmt.Sprintf("profiles.%s: must be a mapping of modules", name)}
+	}
+	var problems []string
+	for i := 0; i+1 < len(profile.Content); i += 2 {
+		module, section := profile.Content[i].Value, profile.Content[i+1]
+		prefix := "profiles." + name + "." + module
+		if findModule(module) == nil {
+			problems = append(problems, fmt.Sprintf("%s: unknown module (modules: %s)", prefix, moduleList()))
+			continue
+		}
+		var own []*command
+		for _, c := range commands {
+			if c.Module.Name == module {
+				own = append(own, c)
+			}
+		}
+		problems = append(problems, validateSection(prefix, section, nil, own)...)
+	}
+	return problems
+}
+
+func validateSection(prefix string, section *yaml.Node, path []string, commands []*command) []string {
+	if section.Kind != yaml.MappingNode {
+		return []string{prefix + ": must be a mapping of flags"}
+	}
+	var problems []string
+	for i := 0; i+1 < len(section.Content); i += 2 {
+		key, value := section.Content[i].Value, section.Content[i+1]
+		name := prefix + "." + key
+
+		if value.Kind == yaml.MappingNode {
+			sub := append(append([]string{}, path...), key)
+			var below []*command
+			for _, c := range commands {
+				if hasPrefix(c.Path, sub) {
+					below = append(below, c)
+				}
+			}
+			if len(below) == 0 {
+				problems = append(problems, fmt.Sprintf("%s: unknown command '%s'", name, strings.Join(sub, " ")))
+				continue
+			}
+			problems = append(problems, validateSection(name, value, sub, below)...)
+			continue
+		}
+
+		var raw interface{}
+		if err := value.Decode(&raw); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		found := false
+		for _, c := range commands {
+			fl := c.Flags.Lookup(key)
+			if fl == nil {
+				continue
+			}
+			found = true
+			if raw == nil {
+				break
+			}
+			for _, v := range values(raw) {
+				if err := fl.Value.Set(v); err != nil {
+					problems = append(problems, fmt.Sprintf("%s: invalid value '%s' for --%s: %v", name, v, key, err))
+				}
+			}
+			break
+		}
+		if !found {
+			problems = append(problems, fmt.Sprintf("%s: no command below %s has a --%s flag", name, prefix, key))
+		}
+	}
+	return problems
+}
+
+// unknownEnv returns a warning per GOD_<MODULE>_* variable that sets no flag
+func unknownEnv(commands []*command) []string {
+	known := make(map[string]bool)
+	for _, c := range commands {
+		for i := 0; i <= len(c.Path); i++ {
+			for _, name := range flagNames(c) {
+				known[envName(c.Module.Name, c.Path[:i], name)] = true
+			}
+		}
+	}
+
+	var warnings []string
+	for _, kv := range os.Environ() {
+		env, _, _ := strings.Cut(kv, "=")
+		for _, m := range modules {
+			if strings.HasPrefix(env, envName(m.Name, nil, "")) && !known[env] {
+				warnings = append(warnings, fmt.Sprintf("%s is set but no %s command has that flag", env, m.Name))
+			}
+		}
+	}
+	sort.Strings(warnings)
+	return warnings
+}
+
+func flagNames(c *command) []string {
+	var names []string
+	c.Flags.VisitAll(func(fl *flag.Flag) {
+		names = append(names, fl.Name)
+	})
+	return names
+}
+
+func moduleList() string {
+	var names []string
+	for _, m := range modules {
+		names = append(names, m.Name)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,118 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// readNode parses the config file into a YAML tree, keeping comments so
+// that god config set doesn't rewrite what it doesn't change. A missing
+// file yields an empty document.
+func readNode() (*yaml.Node, error) {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	data, err := os.ReadFile(Path())
+	if os.IsNotExist(err) {
+		return root, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("%s: %v", Path(), err)
+	}
+	if len(doc.Content) == 0 {
+		return root, nil
+	}
+	if doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s: the top level must be a mapping", Path())
+	}
+	return doc.Content[0], nil
+}
+
+func writeNode(root *yaml.Node) error {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{root}}); err != nil {
+		return err
+	}
+	path := Path()
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	mode := os.FileMode(0o600)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	return os.WriteFile(path, buf.Bytes(), mode)
+}
+
+// mappingValue returns the value of a key of a mapping node, or nil
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	if m == nil || m.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// lookupNode renders the value at a dotted path: scalars as they are,
+// anything else as YAML
+func lookupNode(root *yaml.Node, path []string) (string, error) {
+	n := root
+	for i, key := range path {
+		if n = mappingValue(n, key); n == nil {
+			return "", fmt.Errorf("%s is not set in %s", strings.Join(path[:i+1], "."), Path())
+		}
+	}
+	if n.Kind == yaml.ScalarNode {
+		return n.Value, nil
+	}
+	out, err := yaml.Marshal(n)
+	return strings.TrimRight(string(out), "\n"), err
+}
+
+// setNode sets the scalar at a dotted path, creating mappings on the way
+func setNode(root *yaml.Node, path []string, value string) error {
+	n := root
+	for i, key := range path {
+		last := i == len(path)-1
+		child := mappingValue(n, key)
+		if child == nil {
+			child = &yaml.Node{Kind: yaml.MappingNode}
+			if last {
+				child = &yaml.Node{Kind: yaml.ScalarNode}
+			}
+			n.Content = append(n.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, child)
+		}
+		if last {
+			if child.Kind != yaml.ScalarNode {
+				return fmt.Errorf("%s is a %s, only single values can be set", strings.Join(path, "."), kindName(child))
+			}
+			child.Value, child.Tag, child.Style = value, "", 0
+			return nil
+		}
+		if child.Kind != yaml.MappingNode {
+			return fmt.Errorf("%s is not a mapping", strings.Join(path[:i+1], "."))
+		}
+		n = child
+	}
+	return nil
+}
+
+func kindName(n *yaml.Node) string {
+	if n.Kind == yaml.SequenceNode {
+		return "list"
+	}
+	return "mapping"
+}
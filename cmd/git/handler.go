@@ -2,10 +2,16 @@
 package git
 
 import (
+	"flag"
 	"fmt"
 	"os"
 )
 
+// Commands declares the flags of each git command, for god config
+var Commands = map[string]func() *flag.FlagSet{
+	"pull": func() *flag.FlagSet { return newPullFlags().FlagSet },
+}
+
 // Handle processes the 'god git ...' commands
 func Handle(args []string) {
 	if len(args) < 1 {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"god/cmd/config"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -15,13 +16,28 @@ import (
 // maxConcurrency limits how many git processes run at once to prevent network choking
 const maxConcurrency = 10
 
+// pullFlags holds the flags of god git pull
+type pullFlags struct {
+	*flag.FlagSet
+	workDir *string
+	dryRun  *bool
+	verbose *bool
+}
+
+func newPullFlags() *pullFlags {
+	f := &pullFlags{FlagSet: flag.NewFlagSet("pull", flag.ExitOnError)}
+	f.workDir = f.String("path", ".", "Target directory containing git repositories")
+	f.dryRun = f.Bool("dry-run", false, "Check for updates without modifying files")
+	f.verbose = f.Bool("v", false, "Show detailed git output")
+	return f
+}
+
 func runPull(args []string) {
-	pullCmd := flag.NewFlagSet("pull", flag.ExitOnError)
-	workDir := pullCmd.String("path", ".", "Target directory containing git repositories")
-	dryRun := pullCmd.Bool("dry-run", false, "Check for updates without modifying files")
-	verbose := pullCmd.Bool("v", false, "Show detailed git output")
+	f := newPullFlags()
+	workDir, dryRun, verbose := f.workDir, f.dryRun, f.verbose
 
-	pullCmd.Parse(args)
+	config.Apply(f.FlagSet, "git")
+	f.Parse(args)
 
 	rootPath, _ := filepath.Abs(*workDir)
 
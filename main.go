@@ -3,25 +3,37 @@ package main
 import (
 	"fmt"
 	"god/cmd/alert" // <--- Import the new module
+	"god/cmd/audit"
+	"god/cmd/config"
 	"god/cmd/git"
 	"os"
 )
 
 func main() {
-	if len(os.Args) < 2 {
+	config.Register(config.Module{Name: "git", Commands: git.Commands})
+	config.Register(config.Module{Name: "alert", Commands: alert.Commands, Validate: alert.ValidateConfig})
+	config.Register(config.Module{Name: "audit", Commands: audit.Commands})
+
+	// --config and --profile apply to every module
+	args := config.Init(os.Args[1:])
+	if len(args) < 1 {
 		printHelp()
 		os.Exit(1)
 	}
 
-	switch os.Args[1] {
+	switch args[0] {
 	case "git":
-		git.Handle(os.Args[2:])
+		git.Handle(args[1:])
 	case "alert": // <--- Add this case
-		alert.Handle(os.Args[2:])
+		alert.Handle(args[1:])
+	case "audit":
+		audit.Handle(args[1:])
+	case "config":
+		config.Handle(args[1:])
 	case "help":
 		printHelp()
 	default:
-		fmt.Printf("Unknown module: %s\n", os.Args[1])
+		fmt.Printf("Unknown module: %s\n", args[0])
 		printHelp()
 		os.Exit(1)
 	}
@@ -32,7 +44,14 @@ func printHelp() {
 	fmt.Println("\nAvailable Modules:")
 	fmt.Println("  git    Manage git repositories")
 	fmt.Println("  alert  Check Prometheus alerts") // <--- Update help text
+	fmt.Println("  audit  Show the log of commands god ran against clusters and servers")
+	fmt.Println("  config Show, get, set and validate the config file and profiles")
+	fmt.Println("\nGlobal Flags:")
+	fmt.Println("  --config <file>   Config file (also GOD_CONFIG; default ~/.config/god/config.yaml)")
+	fmt.Println("  --profile <name>  Profile of flag defaults (also GOD_PROFILE)")
 	fmt.Println("\nExample:")
 	fmt.Println("  god git pull --path=./work")
 	fmt.Println("  god alert list")
+	fmt.Println("  god audit show --since 1d")
+	fmt.Println("  god --profile prod-eu alert scan")
 }